@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+	"gitlab.com/jkozhemiaka/web-layout/internal/database"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/mailer"
+	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	myValidate "gitlab.com/jkozhemiaka/web-layout/internal/validate"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// app holds what the commands share: the config, the database and the same
+// services the API uses, so changes made from the command line go through the
+// same validation and hashing. The response cache of the API is shared too, so
+// that writes can drop the entries they make stale.
+type app struct {
+	cfg             *config.Config
+	logger          *zap.SugaredLogger
+	db              *gorm.DB
+	redisClient     *cache.RedisClient
+	cache           cache.CacheInterface
+	validator       *validator.Validate
+	userService     services.UserServiceInterface
+	roleService     services.RoleServiceInterface
+	passwordService services.PasswordServiceInterface
+}
+
+func newApp() (*app, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	logger, err := logging.NewLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.SetupDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mailer, err := mailer.NewMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sugar := logger.Sugar()
+	redisClient, err := cache.NewRedisClient(cfg.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	responseCache, err := cache.NewCache(cfg, redisClient, sugar)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo := repositories.NewUserRepo(db, sugar)
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	return &app{
+		cfg:         cfg,
+		logger:      sugar,
+		db:          db,
+		redisClient: redisClient,
+		cache:       responseCache,
+		validator:   validate,
+		userService: services.NewUserService(userRepo, repositories.NewVoteRepo(db, sugar), responseCache, sugar),
+		roleService: services.NewRoleService(repositories.NewRoleRepo(db, sugar), sugar),
+		passwordService: services.NewPasswordService(
+			userRepo,
+			repositories.NewPasswordResetRepo(db, sugar),
+			repositories.NewPasswordHistoryRepo(db, sugar),
+			repositories.NewAuditRepo(db, sugar),
+			mailer,
+			sugar,
+			cfg,
+		),
+	}, nil
+}
+
+func (a *app) Close() {
+	a.logger.Sync()
+	if tieredCache, ok := a.cache.(*cache.TieredCache); ok {
+		tieredCache.Close()
+	}
+	a.redisClient.Client.Close()
+	if sqlDB, err := a.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// invalidateUserCache drops the cached responses of the user, if any, and every
+// cached user list and count, like the API does after a write. The change is
+// made already when Redis can't be reached, the entries expire on their own then.
+func (a *app) invalidateUserCache(ctx context.Context, userID uint) {
+	ctx, cancel := context.WithTimeout(ctx, a.cfg.CacheOpTimeout)
+	defer cancel()
+
+	if userID != 0 {
+		err := a.cache.Delete(ctx, constants.UserCacheKeyPrefix+strconv.FormatUint(uint64(userID), 10))
+		if err != nil {
+			a.logger.Errorf("Error invalidating cached user %d: %v", userID, err)
+		}
+	}
+	err := a.cache.InvalidateTags(ctx, constants.UsersListCacheTag, constants.UsersCountCacheTag)
+	if err != nil {
+		a.logger.Errorf("Error invalidating cached user lists: %v", err)
+	}
+}
@@ -1,9 +1,70 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"gitlab.com/jkozhemiaka/web-layout/internal/server"
 )
 
+const usage = `Usage:
+  weblayout [serve]                            run the HTTP server
+  weblayout migrate up                         apply the pending migrations
+  weblayout migrate down [-steps N]            revert the last N migrations (default 1)
+  weblayout migrate status                     list the migrations and when they were applied
+  weblayout migrate create [-dir D] NAME       add empty up and down files for a new migration
+  weblayout user create -email E -first-name F -last-name L [-role R] [-password P]
+                                               create a verified user, role user by default
+  weblayout user set-password -email E [-password P]
+                                               set the password and revoke the sessions of the user
+  weblayout user set-role -email E -role R     change the role of the user
+  weblayout user deactivate -email E           soft delete the user
+  weblayout seed [-count N] [-role R] [-password P]
+                                               create N fake users for development
+
+Passwords not given with -password are read from the first line of stdin.
+`
+
 func main() {
-	server.Run()
+	if len(os.Args) < 2 {
+		server.Run()
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		server.Run()
+		return
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "user":
+		err = runUser(os.Args[2:])
+	case "seed":
+		err = runSeed(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		err = usageErrorf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.As(err, new(usageError)) {
+			fmt.Fprint(os.Stderr, usage)
+		}
+		os.Exit(1)
+	}
+}
+
+// usageError is a mistake in the command line, it is followed by the usage
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError(fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/database"
+	"gitlab.com/jkozhemiaka/web-layout/internal/database/migrations"
+)
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("migrate needs one of up, down, status or create")
+	}
+	action, args := args[0], args[1:]
+
+	if action == "create" {
+		flags := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+		dir := flags.String("dir", "internal/database/migrations", "directory of the migration files")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return usageErrorf("migrate create needs a NAME")
+		}
+		paths, err := database.CreateMigration(*dir, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return nil
+	}
+
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case action != "up" && action != "down" && action != "status":
+		return usageErrorf("unknown migrate command %q", action)
+	case *steps < 1:
+		return usageErrorf("-steps must be at least 1")
+	}
+
+	app, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	migrator, err := database.NewMigrator(app.db, migrations.FS, app.logger)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err == nil && len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+	case "down":
+		_, err := migrator.Down(ctx, *steps)
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/handlers"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/passwords"
+)
+
+var (
+	seedFirstNames = []string{"Olena", "Taras", "Iryna", "Andrii", "Sofiia", "Bohdan", "Mariia", "Dmytro"}
+	seedLastNames  = []string{"Shevchenko", "Kovalenko", "Bondarenko", "Tkachenko", "Kravchenko", "Melnyk"}
+)
+
+// runSeed creates fake verified users for local development and load tests.
+// They all share one password, hashed once since bcrypt is slow on purpose.
+func runSeed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	count := flags.Int("count", 10, "number of users to create")
+	password := flags.String("password", "Seed-pass1!", "password of every created user")
+	roleName := flags.String("role", models.StrUser, "role of the created users")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *count < 1 {
+		return usageErrorf("-count must be at least 1")
+	}
+
+	app, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	role, err := app.findRole(ctx, *roleName)
+	if err != nil {
+		return err
+	}
+	hash, err := passwords.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	// The batch prefix keeps the emails unique across runs
+	batch := time.Now().Unix()
+	verifiedAt := time.Now()
+	for i := 1; i <= *count; i++ {
+		request := &handlers.CreateUserRequest{
+			FirstName: seedFirstNames[rand.Intn(len(seedFirstNames))],
+			LastName:  seedLastNames[rand.Intn(len(seedLastNames))],
+			Password:  *password,
+		}
+		request.Email = fmt.Sprintf("%s.%s.%d.%d@example.com", strings.ToLower(request.FirstName), strings.ToLower(request.LastName), batch, i)
+		if err := app.validator.Struct(request); err != nil {
+			return err
+		}
+
+		_, err := app.userService.CreateUser(ctx, &models.User{
+			Email:           request.Email,
+			FirstName:       request.FirstName,
+			LastName:        request.LastName,
+			Password:        hash,
+			RoleID:          role.ID,
+			EmailVerifiedAt: &verifiedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("creating user %d of %d: %w", i, *count, err)
+		}
+	}
+
+	app.invalidateUserCache(ctx, 0)
+
+	fmt.Printf("Created %d users with role %s\n", *count, role.Name)
+	return nil
+}
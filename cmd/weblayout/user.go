@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/handlers"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/passwords"
+)
+
+// systemActorID is recorded in the audit log for changes made from the command line
+const systemActorID = 0
+
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("user needs one of create, set-password, set-role or deactivate")
+	}
+	action, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("user "+action, flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user")
+	var firstName, lastName, password, role *string
+	switch action {
+	case "create":
+		firstName = flags.String("first-name", "", "first name")
+		lastName = flags.String("last-name", "", "last name")
+		password = flags.String("password", "", "password, read from stdin when empty")
+		role = flags.String("role", models.StrUser, "role name")
+	case "set-password":
+		password = flags.String("password", "", "new password, read from stdin when empty")
+	case "set-role":
+		role = flags.String("role", "", "role name")
+	case "deactivate":
+	default:
+		return usageErrorf("unknown user command %q", action)
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return usageErrorf("user %s needs -email", action)
+	}
+	if role != nil && *role == "" {
+		return usageErrorf("user %s needs -role", action)
+	}
+	if password != nil && *password == "" {
+		var err error
+		if *password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+
+	app, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	switch action {
+	case "create":
+		return app.createUser(ctx, &handlers.CreateUserRequest{
+			Email:     *email,
+			FirstName: *firstName,
+			LastName:  *lastName,
+			Password:  *password,
+		}, *role)
+	case "set-password":
+		return app.setPassword(ctx, *email, *password)
+	case "set-role":
+		return app.setRole(ctx, *email, *role)
+	default:
+		return app.deactivateUser(ctx, *email)
+	}
+}
+
+// readPassword reads the password from the first line of stdin, so it doesn't
+// end up in the shell history
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// createUser validates the request like POST /users does. The email is
+// considered verified, the account is created by an operator.
+func (a *app) createUser(ctx context.Context, request *handlers.CreateUserRequest, roleName string) error {
+	if err := a.validator.Struct(request); err != nil {
+		return err
+	}
+	existing, err := a.userService.GetUserByEmail(ctx, request.Email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("email already in use")
+	}
+	role, err := a.findRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	hash, err := passwords.HashPassword(request.Password)
+	if err != nil {
+		return err
+	}
+	verifiedAt := time.Now()
+	userID, err := a.userService.CreateUser(ctx, &models.User{
+		Email:           request.Email,
+		FirstName:       request.FirstName,
+		LastName:        request.LastName,
+		Password:        hash,
+		RoleID:          role.ID,
+		EmailVerifiedAt: &verifiedAt,
+	})
+	if err != nil {
+		return err
+	}
+	a.invalidateUserCache(ctx, userID)
+
+	fmt.Printf("Created user %d (%s) with role %s\n", userID, request.Email, role.Name)
+	return nil
+}
+
+// setPassword goes through the password history check and revokes the sessions
+// of the user, the change is audited as made by the system
+func (a *app) setPassword(ctx context.Context, email, password string) error {
+	if err := a.validator.Struct(&handlers.ChangePasswordRequest{NewPassword: password}); err != nil {
+		return err
+	}
+	user, err := a.findUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if err := a.passwordService.ChangePassword(ctx, systemActorID, user.ID, "", password); err != nil {
+		return err
+	}
+	a.invalidateUserCache(ctx, user.ID)
+
+	fmt.Printf("Changed the password of user %d (%s)\n", user.ID, user.Email)
+	return nil
+}
+
+func (a *app) setRole(ctx context.Context, email, roleName string) error {
+	user, err := a.findUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	role, err := a.findRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	_, err = a.userService.PatchUser(ctx, userIDString(user), &models.UserPatch{RoleID: &role.ID})
+	if err != nil {
+		return err
+	}
+	a.invalidateUserCache(ctx, user.ID)
+
+	fmt.Printf("User %d (%s) now has role %s\n", user.ID, user.Email, role.Name)
+	return nil
+}
+
+// deactivateUser soft deletes the user like DELETE /users/{id}, the tokens of
+// the user stop working
+func (a *app) deactivateUser(ctx context.Context, email string) error {
+	user, err := a.findUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if _, err := a.userService.DeleteUser(ctx, userIDString(user), 0); err != nil {
+		return err
+	}
+	a.invalidateUserCache(ctx, user.ID)
+
+	fmt.Printf("Deactivated user %d (%s)\n", user.ID, user.Email)
+	return nil
+}
+
+func (a *app) findUser(ctx context.Context, email string) (*models.User, error) {
+	user, err := a.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("no user with email %s", email)
+	}
+	return user, nil
+}
+
+func (a *app) findRole(ctx context.Context, name string) (*models.Role, error) {
+	roles, err := a.roleService.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(roles))
+	for i := range roles {
+		if roles[i].Name == name {
+			return &roles[i], nil
+		}
+		names = append(names, roles[i].Name)
+	}
+	return nil, fmt.Errorf("unknown role %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+func userIDString(user *models.User) string {
+	return strconv.FormatUint(uint64(user.ID), 10)
+}
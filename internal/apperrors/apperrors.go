@@ -32,6 +32,11 @@ var (
 		Code:    "NIL_POSTGRES_ERR",
 	}
 
+	JwtKeyConfigError = AppError{
+		Message: "Invalid JWT signing key configuration",
+		Code:    "JWT_KEY_CONFIG_ERR",
+	}
+
 	LoggerInitError = AppError{
 		Message: "Cannot init logger",
 		Code:    "LOGGER_INIT_ERR",
@@ -79,6 +84,135 @@ var (
 		Code:     "UNAUTHORIZED_ERR",
 		HTTPCode: http.StatusUnauthorized,
 	}
+
+	ForbiddenErr = AppError{
+		Message:  "Permission is denied",
+		Code:     "FORBIDDEN_ERR",
+		HTTPCode: http.StatusForbidden,
+	}
+
+	RoleInUseErr = AppError{
+		Message:  "Role is assigned to users and cannot be deleted",
+		Code:     "ROLE_IN_USE",
+		HTTPCode: http.StatusConflict,
+	}
+
+	UnknownPermissionErr = AppError{
+		Message:  "Unknown permission",
+		Code:     "UNKNOWN_PERMISSION",
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	UnknownRoleErr = AppError{
+		Message:  "Unknown role",
+		Code:     "UNKNOWN_ROLE",
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	TooManyLoginAttemptsErr = AppError{
+		Message:  "Too many login attempts, try again later",
+		Code:     "TOO_MANY_LOGIN_ATTEMPTS",
+		HTTPCode: http.StatusTooManyRequests,
+	}
+
+	AccountLockedErr = AppError{
+		Message:  "Account is temporarily locked after too many failed logins",
+		Code:     "ACCOUNT_LOCKED",
+		HTTPCode: http.StatusLocked,
+	}
+
+	// Returned while Redis can't be reached by caches that must not fall back
+	// to process memory, such as the session and login state
+	CacheUnavailableErr = AppError{
+		Message:  "Service is temporarily unavailable",
+		Code:     "CACHE_UNAVAILABLE",
+		HTTPCode: http.StatusServiceUnavailable,
+	}
+
+	EmailNotVerifiedErr = AppError{
+		Message:  "Email address has not been verified",
+		Code:     "EMAIL_NOT_VERIFIED",
+		HTTPCode: http.StatusForbidden,
+	}
+
+	InvalidVerificationTokenErr = AppError{
+		Message:  "Verification token is invalid or expired",
+		Code:     "INVALID_VERIFICATION_TOKEN",
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	InvalidResetTokenErr = AppError{
+		Message:  "Password reset token is invalid, expired or already used",
+		Code:     "INVALID_RESET_TOKEN",
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	InvalidCurrentPasswordErr = AppError{
+		Message:  "Current password is incorrect",
+		Code:     "INVALID_CURRENT_PASSWORD",
+		HTTPCode: http.StatusForbidden,
+	}
+
+	PasswordReusedErr = AppError{
+		Message:  "The new password must differ from the recently used ones",
+		Code:     "PASSWORD_REUSED",
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	PreconditionFailedErr = AppError{
+		Message:  "The user has been modified in the meantime",
+		Code:     "PRECONDITION_FAILED",
+		HTTPCode: http.StatusPreconditionFailed,
+	}
+
+	EmailInUseErr = AppError{
+		Message:  "The email is already used by another user",
+		Code:     "EMAIL_IN_USE",
+		HTTPCode: http.StatusConflict,
+	}
+
+	UserErasedErr = AppError{
+		Message:  "The personal data of the user has been erased",
+		Code:     "USER_ERASED",
+		HTTPCode: http.StatusGone,
+	}
+
+	InvalidPatchErr = AppError{
+		Message:  "Invalid patch document",
+		Code:     "INVALID_PATCH",
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	UnsupportedMediaTypeErr = AppError{
+		Message:  "Unsupported media type",
+		Code:     "UNSUPPORTED_MEDIA_TYPE",
+		HTTPCode: http.StatusUnsupportedMediaType,
+	}
+
+	InvalidRefreshTokenErr = AppError{
+		Message:  "Refresh token is invalid or expired",
+		Code:     "INVALID_REFRESH_TOKEN",
+		HTTPCode: http.StatusUnauthorized,
+	}
+
+	// Returned when an already rotated refresh token is presented again
+	RefreshTokenReusedErr = AppError{
+		Message:  "Refresh token has already been used, session revoked",
+		Code:     "REFRESH_TOKEN_REUSED",
+		HTTPCode: http.StatusUnauthorized,
+	}
+
+	SessionRevokedErr = AppError{
+		Message:  "Session has been revoked",
+		Code:     "SESSION_REVOKED",
+		HTTPCode: http.StatusUnauthorized,
+	}
+
+	TokenGenerationErr = AppError{
+		Message:  "Failed to generate token",
+		Code:     "TOKEN_GENERATION_ERR",
+		HTTPCode: http.StatusInternalServerError,
+	}
 )
 
 func (appError *AppError) Error() string {
@@ -87,8 +221,9 @@ func (appError *AppError) Error() string {
 
 func (appError *AppError) AppendMessage(anyErrs ...interface{}) *AppError {
 	return &AppError{
-		Message: fmt.Sprintf("%v : %v", appError.Message, anyErrs),
-		Code:    appError.Code,
+		Message:  fmt.Sprintf("%v : %v", appError.Message, anyErrs),
+		Code:     appError.Code,
+		HTTPCode: appError.HTTPCode,
 	}
 }
 
@@ -2,9 +2,8 @@ package auth
 
 import (
 	"errors"
-	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v4"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"gitlab.com/jkozhemiaka/web-layout/internal/passwords"
 )
@@ -13,28 +12,13 @@ type Claims struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
 	ID    uint   `json:"user_id"`
+	// SessionID is the refresh token family the access token was issued for
+	SessionID string `json:"sid"`
+	// TokenVersion must match the user's current version for the token to be accepted
+	TokenVersion uint `json:"ver"`
 	jwt.StandardClaims
 }
 
-func GenerateTokenHandler(email, role string, ID uint, JwtKey []byte) []byte {
-	claims := &Claims{
-		Email: email,
-		Role:  role,
-		ID:    ID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(JwtKey)
-	if err != nil {
-		return nil
-	}
-
-	return []byte(tokenString)
-}
-
 func Access(username, password string, user *models.User) error {
 	// Check the password
 	if user == nil {
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet signs tokens with the active key and verifies them with any known key.
+// Rotating a key means adding a new private key, making it active and keeping
+// the old one (or only its public half) until the tokens it signed expire.
+type KeySet struct {
+	method      jwt.SigningMethod
+	activeKeyID string
+	signingKey  interface{}
+	verifyKeys  map[string]interface{}
+}
+
+func NewKeySet(cfg *config.Config) (*KeySet, error) {
+	switch cfg.JwtAlgorithm {
+	case AlgHS256, "":
+		if cfg.JwtKey == "" {
+			return nil, apperrors.JwtKeyConfigError.AppendMessage("JWT_KEY is required for HS256")
+		}
+		return &KeySet{
+			method:     jwt.SigningMethodHS256,
+			signingKey: []byte(cfg.JwtKey),
+			verifyKeys: map[string]interface{}{"": []byte(cfg.JwtKey)},
+		}, nil
+	case AlgRS256:
+		return newAsymmetricKeySet(cfg, jwt.SigningMethodRS256, parseRSAPrivateKey, parseRSAPublicKey)
+	case AlgEdDSA:
+		return newAsymmetricKeySet(cfg, jwt.SigningMethodEdDSA, parseEdPrivateKey, parseEdPublicKey)
+	default:
+		return nil, apperrors.JwtKeyConfigError.AppendMessage("unsupported JWT_ALGORITHM " + cfg.JwtAlgorithm)
+	}
+}
+
+type privateKeyParser func(data []byte) (private, public interface{}, err error)
+type publicKeyParser func(data []byte) (interface{}, error)
+
+func newAsymmetricKeySet(cfg *config.Config, method jwt.SigningMethod, parsePrivate privateKeyParser, parsePublic publicKeyParser) (*KeySet, error) {
+	keySet := &KeySet{
+		method:      method,
+		activeKeyID: cfg.JwtActiveKeyID,
+		verifyKeys:  map[string]interface{}{},
+	}
+
+	for kid, path := range cfg.JwtPrivateKeys {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, apperrors.JwtKeyConfigError.AppendMessage(err)
+		}
+		private, public, err := parsePrivate(data)
+		if err != nil {
+			return nil, apperrors.JwtKeyConfigError.AppendMessage(kid, err)
+		}
+		if kid == cfg.JwtActiveKeyID {
+			keySet.signingKey = private
+		}
+		keySet.verifyKeys[kid] = public
+	}
+
+	// Public keys of retired signers, kept only to verify tokens they issued
+	for kid, path := range cfg.JwtPublicKeys {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, apperrors.JwtKeyConfigError.AppendMessage(err)
+		}
+		public, err := parsePublic(data)
+		if err != nil {
+			return nil, apperrors.JwtKeyConfigError.AppendMessage(kid, err)
+		}
+		keySet.verifyKeys[kid] = public
+	}
+
+	if keySet.signingKey == nil {
+		return nil, apperrors.JwtKeyConfigError.AppendMessage("no private key with JWT_ACTIVE_KEY_ID " + cfg.JwtActiveKeyID)
+	}
+
+	return keySet, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	if ks.activeKeyID != "" {
+		token.Header["kid"] = ks.activeKeyID
+	}
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc picks the verification key for a token. Tokens signed with another
+// algorithm than the configured one are rejected.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != ks.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if ks.method == jwt.SigningMethodHS256 {
+		kid = ""
+	}
+
+	key, ok := ks.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// JWKS returns the public verification keys. Nothing is published for HS256
+// because the secret must never leave the service.
+func (ks *KeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+
+	kids := make([]string, 0, len(ks.verifyKeys))
+	for kid := range ks.verifyKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		switch key := ks.verifyKeys[kid].(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: AlgRS256,
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: AlgEdDSA,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(key),
+			})
+		}
+	}
+
+	return jwks
+}
+
+func parseRSAPrivateKey(data []byte) (interface{}, interface{}, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, &key.PublicKey, nil
+}
+
+func parseRSAPublicKey(data []byte) (interface{}, error) {
+	return jwt.ParseRSAPublicKeyFromPEM(data)
+}
+
+func parseEdPrivateKey(data []byte) (interface{}, interface{}, error) {
+	key, err := jwt.ParseEdPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("not an Ed25519 private key")
+	}
+	return key, signer.Public(), nil
+}
+
+func parseEdPublicKey(data []byte) (interface{}, error) {
+	return jwt.ParseEdPublicKeyFromPEM(data)
+}
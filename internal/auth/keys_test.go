@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+// writePrivateKey stores the key as a PKCS#8 PEM file and returns its path
+func writePrivateKey(t *testing.T, name string, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), name+".pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	assert.NoError(t, err)
+	return path
+}
+
+func testClaims() *Claims {
+	return &Claims{
+		ID:             1,
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()},
+	}
+}
+
+func TestKeySet_RS256Rotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	oldPath := writePrivateKey(t, "old", oldKey)
+	newPath := writePrivateKey(t, "new", newKey)
+
+	oldKeySet, err := NewKeySet(&config.Config{
+		JwtAlgorithm:   AlgRS256,
+		JwtActiveKeyID: "old",
+		JwtPrivateKeys: map[string]string{"old": oldPath},
+	})
+	assert.NoError(t, err)
+	oldToken, err := oldKeySet.Sign(testClaims())
+	assert.NoError(t, err)
+
+	// After rotation the old key still verifies the tokens it has signed
+	rotatedKeySet, err := NewKeySet(&config.Config{
+		JwtAlgorithm:   AlgRS256,
+		JwtActiveKeyID: "new",
+		JwtPrivateKeys: map[string]string{"old": oldPath, "new": newPath},
+	})
+	assert.NoError(t, err)
+
+	token, err := jwt.ParseWithClaims(oldToken, &Claims{}, rotatedKeySet.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", token.Header["kid"])
+
+	newToken, err := rotatedKeySet.Sign(testClaims())
+	assert.NoError(t, err)
+	token, err = jwt.ParseWithClaims(newToken, &Claims{}, rotatedKeySet.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+
+	jwks := rotatedKeySet.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+}
+
+func TestKeySet_EdDSA(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	keySet, err := NewKeySet(&config.Config{
+		JwtAlgorithm:   AlgEdDSA,
+		JwtActiveKeyID: "ed1",
+		JwtPrivateKeys: map[string]string{"ed1": writePrivateKey(t, "ed1", privateKey)},
+	})
+	assert.NoError(t, err)
+
+	tokenStr, err := keySet.Sign(testClaims())
+	assert.NoError(t, err)
+	_, err = jwt.ParseWithClaims(tokenStr, &Claims{}, keySet.Keyfunc)
+	assert.NoError(t, err)
+
+	jwks := keySet.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+}
+
+func TestKeySet_RejectsOtherAlgorithm(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	keySet, err := NewKeySet(&config.Config{
+		JwtAlgorithm:   AlgEdDSA,
+		JwtActiveKeyID: "ed1",
+		JwtPrivateKeys: map[string]string{"ed1": writePrivateKey(t, "ed1", privateKey)},
+	})
+	assert.NoError(t, err)
+
+	// A token signed with HS256 must not be accepted by an EdDSA key set
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	hmacToken.Header["kid"] = "ed1"
+	tokenStr, err := hmacToken.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	_, err = jwt.ParseWithClaims(tokenStr, &Claims{}, keySet.Keyfunc)
+	assert.Error(t, err)
+
+	hmacKeySet, err := NewKeySet(&config.Config{JwtKey: "secret"})
+	assert.NoError(t, err)
+	assert.Empty(t, hmacKeySet.JWKS().Keys)
+}
+
+func TestKeySet_MissingActiveKey(t *testing.T) {
+	_, err := NewKeySet(&config.Config{JwtAlgorithm: AlgRS256, JwtActiveKeyID: "missing"})
+	assert.Error(t, err)
+
+	_, err = NewKeySet(&config.Config{JwtAlgorithm: "none"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+)
+
+type LoginThrottlerInterface interface {
+	// Check returns an error and the time the client has to wait when the login
+	// attempt must be rejected without checking the password
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	RegisterFailure(ctx context.Context, email, ip string) error
+	RegisterSuccess(ctx context.Context, email string) error
+	Unlock(ctx context.Context, email string) error
+}
+
+type LoginThrottler struct {
+	cache           cache.CacheInterface
+	maxAttempts     int
+	ipMaxAttempts   int
+	window          time.Duration
+	lockoutDuration time.Duration
+	backoffBase     time.Duration
+	backoffMax      time.Duration
+}
+
+func NewLoginThrottler(cache cache.CacheInterface, cfg *config.Config) *LoginThrottler {
+	return &LoginThrottler{
+		cache:           cache,
+		maxAttempts:     cfg.LoginMaxAttempts,
+		ipMaxAttempts:   cfg.LoginIPMaxAttempts,
+		window:          cfg.LoginAttemptWindow,
+		lockoutDuration: cfg.LoginLockoutDuration,
+		backoffBase:     cfg.LoginBackoffBase,
+		backoffMax:      cfg.LoginBackoffMax,
+	}
+}
+
+func (t *LoginThrottler) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	email = normalizeEmail(email)
+
+	wait, err := t.waitUntil(ctx, constants.LoginLockKeyPrefix+email)
+	if err != nil {
+		return 0, err
+	}
+	if wait > 0 {
+		return wait, &apperrors.AccountLockedErr
+	}
+
+	wait, err = t.waitUntil(ctx, constants.LoginBackoffKeyPrefix+email)
+	if err != nil {
+		return 0, err
+	}
+	if wait > 0 {
+		return wait, &apperrors.TooManyLoginAttemptsErr
+	}
+
+	ipFailures, err := t.counter(ctx, constants.LoginFailuresIPKeyPrefix+ip)
+	if err != nil {
+		return 0, err
+	}
+	if t.ipMaxAttempts > 0 && ipFailures >= t.ipMaxAttempts {
+		return t.window, &apperrors.TooManyLoginAttemptsErr
+	}
+
+	return 0, nil
+}
+
+// RegisterFailure counts the failed attempt and either delays the next one
+// exponentially or locks the account once the limit is reached
+func (t *LoginThrottler) RegisterFailure(ctx context.Context, email, ip string) error {
+	email = normalizeEmail(email)
+
+	_, err := t.cache.Incr(ctx, constants.LoginFailuresIPKeyPrefix+ip, t.window)
+	if err != nil {
+		return err
+	}
+
+	failures, err := t.cache.Incr(ctx, constants.LoginFailuresEmailKeyPrefix+email, t.window)
+	if err != nil {
+		return err
+	}
+
+	if t.maxAttempts > 0 && int(failures) >= t.maxAttempts {
+		err = t.setDeadline(ctx, constants.LoginLockKeyPrefix+email, t.lockoutDuration)
+		if err != nil {
+			return err
+		}
+		return t.reset(ctx, constants.LoginFailuresEmailKeyPrefix+email)
+	}
+
+	return t.setDeadline(ctx, constants.LoginBackoffKeyPrefix+email, t.backoff(int(failures)))
+}
+
+func (t *LoginThrottler) RegisterSuccess(ctx context.Context, email string) error {
+	return t.reset(ctx, constants.LoginFailuresEmailKeyPrefix+normalizeEmail(email))
+}
+
+// Unlock lifts the lockout and the backoff of the account
+func (t *LoginThrottler) Unlock(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+
+	for _, prefix := range []string{constants.LoginLockKeyPrefix, constants.LoginBackoffKeyPrefix, constants.LoginFailuresEmailKeyPrefix} {
+		if err := t.reset(ctx, prefix+email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff returns base * 2^(failures-1) capped by the configured maximum
+func (t *LoginThrottler) backoff(failures int) time.Duration {
+	delay := t.backoffBase
+	for i := 1; i < failures && delay < t.backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > t.backoffMax {
+		delay = t.backoffMax
+	}
+	return delay
+}
+
+// setDeadline stores the moment until which logins are refused
+func (t *LoginThrottler) setDeadline(ctx context.Context, key string, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+	deadline := time.Now().Add(duration).UnixNano()
+	return t.cache.Set(ctx, key, strconv.FormatInt(deadline, 10), duration)
+}
+
+func (t *LoginThrottler) waitUntil(ctx context.Context, key string) (time.Duration, error) {
+	value, err := t.cache.Get(ctx, key, 0)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	deadline, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return time.Until(time.Unix(0, deadline)), nil
+}
+
+func (t *LoginThrottler) counter(ctx context.Context, key string) (int, error) {
+	value, err := t.cache.Get(ctx, key, 0)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	count, _ := strconv.Atoi(value)
+	return count, nil
+}
+
+func (t *LoginThrottler) reset(ctx context.Context, key string) error {
+	return t.cache.Delete(ctx, key)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+func newTestLoginThrottler(t *testing.T, cfg *config.Config) *LoginThrottler {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	return NewLoginThrottler(newMapCache(ctrl), cfg)
+}
+
+func TestLoginThrottler_Backoff(t *testing.T) {
+	throttler := newTestLoginThrottler(t, &config.Config{
+		LoginMaxAttempts:   5,
+		LoginAttemptWindow: time.Minute,
+		LoginBackoffBase:   time.Second,
+		LoginBackoffMax:    4 * time.Second,
+	})
+
+	assert.Equal(t, time.Second, throttler.backoff(1))
+	assert.Equal(t, 2*time.Second, throttler.backoff(2))
+	assert.Equal(t, 4*time.Second, throttler.backoff(3))
+	assert.Equal(t, 4*time.Second, throttler.backoff(10))
+
+	ctx := context.Background()
+	_, err := throttler.Check(ctx, "test@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+
+	err = throttler.RegisterFailure(ctx, "test@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+
+	retryAfter, err := throttler.Check(ctx, "Test@Example.com", "10.0.0.1")
+	assert.True(t, apperrors.Is(err, &apperrors.TooManyLoginAttemptsErr))
+	assert.InDelta(t, time.Second, retryAfter, float64(100*time.Millisecond))
+}
+
+func TestLoginThrottler_LockoutAndUnlock(t *testing.T) {
+	throttler := newTestLoginThrottler(t, &config.Config{
+		LoginMaxAttempts:     3,
+		LoginAttemptWindow:   time.Minute,
+		LoginLockoutDuration: 10 * time.Minute,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		err := throttler.RegisterFailure(ctx, "test@example.com", "10.0.0.1")
+		assert.NoError(t, err)
+	}
+
+	retryAfter, err := throttler.Check(ctx, "test@example.com", "10.0.0.1")
+	assert.True(t, apperrors.Is(err, &apperrors.AccountLockedErr))
+	assert.Greater(t, retryAfter, 9*time.Minute)
+
+	err = throttler.Unlock(ctx, "test@example.com")
+	assert.NoError(t, err)
+
+	_, err = throttler.Check(ctx, "test@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginThrottler_IPLimit(t *testing.T) {
+	throttler := newTestLoginThrottler(t, &config.Config{
+		LoginIPMaxAttempts: 2,
+		LoginAttemptWindow: time.Minute,
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, throttler.RegisterFailure(ctx, "first@example.com", "10.0.0.1"))
+	assert.NoError(t, throttler.RegisterFailure(ctx, "second@example.com", "10.0.0.1"))
+
+	retryAfter, err := throttler.Check(ctx, "third@example.com", "10.0.0.1")
+	assert.True(t, apperrors.Is(err, &apperrors.TooManyLoginAttemptsErr))
+	assert.Equal(t, time.Minute, retryAfter)
+
+	_, err = throttler.Check(ctx, "third@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/auth/login_throttler.go
+
+// Package auth is a generated GoMock package.
+package auth
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLoginThrottlerInterface is a mock of LoginThrottlerInterface interface.
+type MockLoginThrottlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginThrottlerInterfaceMockRecorder
+}
+
+// MockLoginThrottlerInterfaceMockRecorder is the mock recorder for MockLoginThrottlerInterface.
+type MockLoginThrottlerInterfaceMockRecorder struct {
+	mock *MockLoginThrottlerInterface
+}
+
+// NewMockLoginThrottlerInterface creates a new mock instance.
+func NewMockLoginThrottlerInterface(ctrl *gomock.Controller) *MockLoginThrottlerInterface {
+	mock := &MockLoginThrottlerInterface{ctrl: ctrl}
+	mock.recorder = &MockLoginThrottlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginThrottlerInterface) EXPECT() *MockLoginThrottlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLoginThrottlerInterface) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, email, ip)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLoginThrottlerInterfaceMockRecorder) Check(ctx, email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginThrottlerInterface)(nil).Check), ctx, email, ip)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockLoginThrottlerInterface) RegisterFailure(ctx context.Context, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockLoginThrottlerInterfaceMockRecorder) RegisterFailure(ctx, email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockLoginThrottlerInterface)(nil).RegisterFailure), ctx, email, ip)
+}
+
+// RegisterSuccess mocks base method.
+func (m *MockLoginThrottlerInterface) RegisterSuccess(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterSuccess", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterSuccess indicates an expected call of RegisterSuccess.
+func (mr *MockLoginThrottlerInterfaceMockRecorder) RegisterSuccess(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterSuccess", reflect.TypeOf((*MockLoginThrottlerInterface)(nil).RegisterSuccess), ctx, email)
+}
+
+// Unlock mocks base method.
+func (m *MockLoginThrottlerInterface) Unlock(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockLoginThrottlerInterfaceMockRecorder) Unlock(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockLoginThrottlerInterface)(nil).Unlock), ctx, email)
+}
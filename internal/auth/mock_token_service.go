@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/auth/token_service.go
+
+// Package auth is a generated GoMock package.
+package auth
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockTokenServiceInterface is a mock of TokenServiceInterface interface.
+type MockTokenServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenServiceInterfaceMockRecorder
+}
+
+// MockTokenServiceInterfaceMockRecorder is the mock recorder for MockTokenServiceInterface.
+type MockTokenServiceInterfaceMockRecorder struct {
+	mock *MockTokenServiceInterface
+}
+
+// NewMockTokenServiceInterface creates a new mock instance.
+func NewMockTokenServiceInterface(ctrl *gomock.Controller) *MockTokenServiceInterface {
+	mock := &MockTokenServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockTokenServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenServiceInterface) EXPECT() *MockTokenServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ConsumeEmailVerificationToken mocks base method.
+func (m *MockTokenServiceInterface) ConsumeEmailVerificationToken(ctx context.Context, tokenStr string) (*VerificationClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerificationToken", ctx, tokenStr)
+	ret0, _ := ret[0].(*VerificationClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailVerificationToken indicates an expected call of ConsumeEmailVerificationToken.
+func (mr *MockTokenServiceInterfaceMockRecorder) ConsumeEmailVerificationToken(ctx, tokenStr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerificationToken", reflect.TypeOf((*MockTokenServiceInterface)(nil).ConsumeEmailVerificationToken), ctx, tokenStr)
+}
+
+// IssueEmailVerificationToken mocks base method.
+func (m *MockTokenServiceInterface) IssueEmailVerificationToken(ctx context.Context, user *models.User) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueEmailVerificationToken", ctx, user)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueEmailVerificationToken indicates an expected call of IssueEmailVerificationToken.
+func (mr *MockTokenServiceInterfaceMockRecorder) IssueEmailVerificationToken(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueEmailVerificationToken", reflect.TypeOf((*MockTokenServiceInterface)(nil).IssueEmailVerificationToken), ctx, user)
+}
+
+// IssueTokens mocks base method.
+func (m *MockTokenServiceInterface) IssueTokens(ctx context.Context, user *models.User) (*TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", ctx, user)
+	ret0, _ := ret[0].(*TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockTokenServiceInterfaceMockRecorder) IssueTokens(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockTokenServiceInterface)(nil).IssueTokens), ctx, user)
+}
+
+// JWKS mocks base method.
+func (m *MockTokenServiceInterface) JWKS() *JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(*JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockTokenServiceInterfaceMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockTokenServiceInterface)(nil).JWKS))
+}
+
+// ParseAccessToken mocks base method.
+func (m *MockTokenServiceInterface) ParseAccessToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseAccessToken", ctx, tokenStr)
+	ret0, _ := ret[0].(*Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseAccessToken indicates an expected call of ParseAccessToken.
+func (mr *MockTokenServiceInterfaceMockRecorder) ParseAccessToken(ctx, tokenStr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseAccessToken", reflect.TypeOf((*MockTokenServiceInterface)(nil).ParseAccessToken), ctx, tokenStr)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockTokenServiceInterface) RefreshTokens(ctx context.Context, refreshToken string, loadUser UserLoader) (*TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, refreshToken, loadUser)
+	ret0, _ := ret[0].(*TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockTokenServiceInterfaceMockRecorder) RefreshTokens(ctx, refreshToken, loadUser interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockTokenServiceInterface)(nil).RefreshTokens), ctx, refreshToken, loadUser)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockTokenServiceInterface) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockTokenServiceInterfaceMockRecorder) RevokeRefreshToken(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockTokenServiceInterface)(nil).RevokeRefreshToken), ctx, refreshToken)
+}
@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"` // Missing when the session couldn't be stored in Redis
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// refreshTokenRecord is what we keep in the cache for every issued refresh token.
+// The token itself is never stored, only its SHA-256 hash is used as the key.
+type refreshTokenRecord struct {
+	UserID       uint   `json:"user_id"`
+	FamilyID     string `json:"family_id"`
+	Status       string `json:"status"`
+	TokenVersion uint   `json:"token_version"`
+}
+
+// UserLoader fetches the current state of the token owner during refresh
+type UserLoader func(ctx context.Context, userID uint) (*models.User, error)
+
+type TokenServiceInterface interface {
+	IssueTokens(ctx context.Context, user *models.User) (*TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string, loadUser UserLoader) (*TokenPair, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	ParseAccessToken(ctx context.Context, tokenStr string) (*Claims, error)
+	JWKS() *JWKS
+	IssueEmailVerificationToken(ctx context.Context, user *models.User) (string, error)
+	ConsumeEmailVerificationToken(ctx context.Context, tokenStr string) (*VerificationClaims, error)
+}
+
+const purposeEmailVerification = "email_verification"
+
+// VerificationClaims are carried by the one-time token sent to confirm an email address
+type VerificationClaims struct {
+	UserID  uint   `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+type TokenService struct {
+	cache           cache.CacheInterface
+	keySet          *KeySet
+	accessTTL       time.Duration
+	refreshTTL      time.Duration
+	verificationTTL time.Duration
+}
+
+func NewTokenService(cache cache.CacheInterface, keySet *KeySet, cfg *config.Config) *TokenService {
+	return &TokenService{
+		cache:           cache,
+		keySet:          keySet,
+		accessTTL:       cfg.AccessTokenTTL,
+		refreshTTL:      cfg.RefreshTokenTTL,
+		verificationTTL: cfg.EmailVerificationTTL,
+	}
+}
+
+// IssueTokens starts a new session (token family) for the user. While Redis is
+// unreachable the refresh token can't be stored, the user gets only an access
+// token and logs in again once it expires.
+func (s *TokenService) IssueTokens(ctx context.Context, user *models.User) (*TokenPair, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, apperrors.TokenGenerationErr.AppendMessage(err)
+	}
+
+	tokens, err := s.issue(ctx, user, familyID)
+	if apperrors.Is(err, &apperrors.CacheUnavailableErr) {
+		accessToken, err := s.generateAccessToken(user, familyID)
+		if err != nil {
+			return nil, apperrors.TokenGenerationErr.AppendMessage(err)
+		}
+		return &TokenPair{
+			AccessToken: accessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int64(s.accessTTL.Seconds()),
+		}, nil
+	}
+	return tokens, err
+}
+
+// RefreshTokens rotates the refresh token: the presented token is marked as used
+// and a new pair from the same family is issued for the user returned by loadUser.
+// Presenting a token that was already used revokes the whole family. The token
+// is claimed with SetNX, so of two concurrent requests with it only one wins.
+func (s *TokenService) RefreshTokens(ctx context.Context, refreshToken string, loadUser UserLoader) (*TokenPair, error) {
+	hash, record, err := s.getRefreshRecord(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.isFamilyRevoked(ctx, record.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, &apperrors.SessionRevokedErr
+	}
+
+	claimed := false
+	if record.Status == constants.TokenStatusActive {
+		claimed, err = s.cache.SetNX(ctx, constants.UsedRefreshTokenKeyPrefix+hash, constants.TokenStatusUsed, s.refreshTTL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !claimed {
+		// Reuse of a rotated token means it has leaked, kill the whole session
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, &apperrors.RefreshTokenReusedErr
+	}
+
+	user, err := loadUser(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Password or role changed since the session started
+	if user.TokenVersion != record.TokenVersion {
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, &apperrors.SessionRevokedErr
+	}
+
+	return s.issue(ctx, user, record.FamilyID)
+}
+
+// RevokeRefreshToken revokes the session the refresh token belongs to
+func (s *TokenService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	_, record, err := s.getRefreshRecord(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return s.revokeFamily(ctx, record.FamilyID)
+}
+
+// ParseAccessToken verifies the signature and expiry of the access token and
+// checks that its session has not been revoked. While Redis is unreachable the
+// session check is skipped: sessions can't be revoked during the outage since
+// refresh and logout fail closed, and the token version is still checked
+// against Postgres by the caller.
+func (s *TokenService) ParseAccessToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, s.keySet.Keyfunc)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.SessionID == "" {
+		return nil, errors.New("token haven't info about session")
+	}
+
+	revoked, err := s.isFamilyRevoked(ctx, claims.SessionID)
+	if err != nil && !apperrors.Is(err, &apperrors.CacheUnavailableErr) {
+		return nil, err
+	}
+	if revoked {
+		return nil, &apperrors.SessionRevokedErr
+	}
+
+	return claims, nil
+}
+
+// IssueEmailVerificationToken signs a token bound to the user and the current email address
+func (s *TokenService) IssueEmailVerificationToken(ctx context.Context, user *models.User) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", apperrors.TokenGenerationErr.AppendMessage(err)
+	}
+
+	now := time.Now()
+	claims := &VerificationClaims{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Purpose: purposeEmailVerification,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.verificationTTL).Unix(),
+		},
+	}
+
+	token, err := s.keySet.Sign(claims)
+	if err != nil {
+		return "", apperrors.TokenGenerationErr.AppendMessage(err)
+	}
+	return token, nil
+}
+
+// ConsumeEmailVerificationToken verifies the token and makes sure it is used only once
+func (s *TokenService) ConsumeEmailVerificationToken(ctx context.Context, tokenStr string) (*VerificationClaims, error) {
+	claims := &VerificationClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, s.keySet.Keyfunc)
+	if err != nil || !token.Valid || claims.Purpose != purposeEmailVerification || claims.Id == "" {
+		return nil, &apperrors.InvalidVerificationTokenErr
+	}
+
+	// Claim the token until it would expire anyway, a second claim fails even
+	// when both requests arrive at the same time
+	usedKey := constants.UsedVerificationTokenKeyPrefix + claims.Id
+	claimed, err := s.cache.SetNX(ctx, usedKey, constants.TokenStatusUsed, time.Until(time.Unix(claims.ExpiresAt, 0)))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, &apperrors.InvalidVerificationTokenErr
+	}
+
+	return claims, nil
+}
+
+func (s *TokenService) issue(ctx context.Context, user *models.User, familyID string) (*TokenPair, error) {
+	accessToken, err := s.generateAccessToken(user, familyID)
+	if err != nil {
+		return nil, apperrors.TokenGenerationErr.AppendMessage(err)
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, apperrors.TokenGenerationErr.AppendMessage(err)
+	}
+
+	record := &refreshTokenRecord{
+		UserID:       user.ID,
+		FamilyID:     familyID,
+		Status:       constants.TokenStatusActive,
+		TokenVersion: user.TokenVersion,
+	}
+	if err := s.setRefreshRecord(ctx, HashOpaqueToken(refreshToken), record); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *TokenService) generateAccessToken(user *models.User, familyID string) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		Email:        user.Email,
+		Role:         user.Role.Name,
+		ID:           user.ID,
+		SessionID:    familyID,
+		TokenVersion: user.TokenVersion,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.accessTTL).Unix(),
+		},
+	}
+
+	return s.keySet.Sign(claims)
+}
+
+// JWKS returns the public keys other services can use to verify our tokens
+func (s *TokenService) JWKS() *JWKS {
+	return s.keySet.JWKS()
+}
+
+func (s *TokenService) getRefreshRecord(ctx context.Context, refreshToken string) (string, *refreshTokenRecord, error) {
+	if refreshToken == "" {
+		return "", nil, &apperrors.InvalidRefreshTokenErr
+	}
+
+	hash := HashOpaqueToken(refreshToken)
+	data, err := s.cache.Get(ctx, constants.RefreshTokenKeyPrefix+hash, s.refreshTTL)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return "", nil, &apperrors.InvalidRefreshTokenErr
+		}
+		return "", nil, err
+	}
+
+	record := &refreshTokenRecord{}
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		return "", nil, apperrors.InvalidRefreshTokenErr.AppendMessage(err)
+	}
+
+	return hash, record, nil
+}
+
+func (s *TokenService) setRefreshRecord(ctx context.Context, hash string, record *refreshTokenRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.cache.Set(ctx, constants.RefreshTokenKeyPrefix+hash, string(data), s.refreshTTL)
+}
+
+func (s *TokenService) isFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	_, err := s.cache.Get(ctx, constants.TokenFamilyKeyPrefix+familyID, s.refreshTTL)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+func (s *TokenService) revokeFamily(ctx context.Context, familyID string) error {
+	// The marker lives as long as any refresh token of the family could
+	return s.cache.Set(ctx, constants.TokenFamilyKeyPrefix+familyID, constants.TokenStatusRevoked, s.refreshTTL)
+}
+
+// NewOpaqueToken returns a random URL-safe token and the hash to store instead of it
+func NewOpaqueToken() (token string, hash string, err error) {
+	token, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex encoded SHA-256 of the token
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
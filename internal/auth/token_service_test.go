@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// newMapCache returns a cache mock that keeps its state in a map
+func newMapCache(ctrl *gomock.Controller) *cache.MockCacheInterface {
+	storage := map[string]string{}
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key string, cacheTTL time.Duration) (string, error) {
+			value, ok := storage[key]
+			if !ok {
+				return "", cache.ErrCacheMiss
+			}
+			return value, nil
+		}).AnyTimes()
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key, value string, cacheTTL time.Duration) error {
+			storage[key] = value
+			return nil
+		}).AnyTimes()
+	mockCache.EXPECT().SetNX(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key, value string, cacheTTL time.Duration) (bool, error) {
+			if _, ok := storage[key]; ok {
+				return false, nil
+			}
+			storage[key] = value
+			return true, nil
+		}).AnyTimes()
+	mockCache.EXPECT().Incr(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key string, cacheTTL time.Duration) (int64, error) {
+			count, _ := strconv.ParseInt(storage[key], 10, 64)
+			count++
+			storage[key] = strconv.FormatInt(count, 10)
+			return count, nil
+		}).AnyTimes()
+	mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, keys ...string) error {
+			for _, key := range keys {
+				delete(storage, key)
+			}
+			return nil
+		}).AnyTimes()
+	return mockCache
+}
+
+func newTestTokenService(t *testing.T) *TokenService {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	cfg := &config.Config{
+		JwtKey:               "test-key",
+		AccessTokenTTL:       time.Minute,
+		RefreshTokenTTL:      time.Hour,
+		EmailVerificationTTL: time.Hour,
+	}
+	keySet, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	return NewTokenService(newMapCache(ctrl), keySet, cfg)
+}
+
+func TestTokenService_IssueAndParse(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, int64(60), tokens.ExpiresIn)
+
+	claims, err := tokenService.ParseAccessToken(context.Background(), tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.ID)
+	assert.Equal(t, models.StrUser, claims.Role)
+	assert.NotEmpty(t, claims.SessionID)
+}
+
+func TestTokenService_RedisOutage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Config{
+		JwtKey:          "test-key",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	}
+	keySet, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+
+	// The breaker is open, so Redis is never called
+	breaker := cache.NewCircuitBreaker(1, time.Hour, zap.NewNop().Sugar())
+	breaker.Trip(errors.New("connection refused"))
+	tokenCache := cache.NewResilientCache(cache.NewMockCacheInterface(ctrl), nil, breaker, time.Second)
+	tokenService := NewTokenService(tokenCache, keySet, cfg)
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+
+	// Logging in still works, without a session that could be refreshed
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Empty(t, tokens.RefreshToken)
+
+	claims, err := tokenService.ParseAccessToken(context.Background(), tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.ID)
+
+	// Refresh and logout fail closed
+	_, err = tokenService.RefreshTokens(context.Background(), "refresh-token", nil)
+	assert.True(t, apperrors.Is(err, &apperrors.CacheUnavailableErr))
+	err = tokenService.RevokeRefreshToken(context.Background(), "refresh-token")
+	assert.True(t, apperrors.Is(err, &apperrors.CacheUnavailableErr))
+}
+
+func TestTokenService_RefreshRotation(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+	loadUser := func(ctx context.Context, userID uint) (*models.User, error) {
+		return user, nil
+	}
+
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+
+	rotated, err := tokenService.RefreshTokens(context.Background(), tokens.RefreshToken, loadUser)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+
+	// Reusing the old refresh token revokes the whole family
+	_, err = tokenService.RefreshTokens(context.Background(), tokens.RefreshToken, loadUser)
+	assert.True(t, apperrors.Is(err, &apperrors.RefreshTokenReusedErr))
+
+	_, err = tokenService.RefreshTokens(context.Background(), rotated.RefreshToken, loadUser)
+	assert.True(t, apperrors.Is(err, &apperrors.SessionRevokedErr))
+
+	_, err = tokenService.ParseAccessToken(context.Background(), rotated.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestTokenService_ConcurrentRefresh(t *testing.T) {
+	cfg := &config.Config{JwtKey: "test-key", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour}
+	keySet, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	tokenService := NewTokenService(cache.NewMemoryCache(100), keySet, cfg)
+
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+	loadUser := func(ctx context.Context, userID uint) (*models.User, error) {
+		return user, nil
+	}
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+
+	// Only one of the requests presenting the same token may rotate it
+	const requests = 10
+	var wg sync.WaitGroup
+	var rotated int32
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tokenService.RefreshTokens(context.Background(), tokens.RefreshToken, loadUser); err == nil {
+				atomic.AddInt32(&rotated, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), rotated)
+}
+
+func TestTokenService_Revoke(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+
+	err = tokenService.RevokeRefreshToken(context.Background(), tokens.RefreshToken)
+	assert.NoError(t, err)
+
+	_, err = tokenService.ParseAccessToken(context.Background(), tokens.AccessToken)
+	assert.True(t, apperrors.Is(err, &apperrors.SessionRevokedErr))
+
+	_, err = tokenService.RefreshTokens(context.Background(), "unknown", nil)
+	assert.True(t, apperrors.Is(err, &apperrors.InvalidRefreshTokenErr))
+}
+
+func TestTokenService_EmailVerificationToken(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+
+	token, err := tokenService.IssueEmailVerificationToken(context.Background(), user)
+	assert.NoError(t, err)
+
+	claims, err := tokenService.ConsumeEmailVerificationToken(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.Equal(t, "test@example.com", claims.Email)
+
+	// The token can only be used once
+	_, err = tokenService.ConsumeEmailVerificationToken(context.Background(), token)
+	assert.True(t, apperrors.Is(err, &apperrors.InvalidVerificationTokenErr))
+
+	// Access tokens are not accepted as verification tokens
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+	_, err = tokenService.ConsumeEmailVerificationToken(context.Background(), tokens.AccessToken)
+	assert.True(t, apperrors.Is(err, &apperrors.InvalidVerificationTokenErr))
+}
+
+func TestTokenService_RefreshAfterPasswordChange(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	user := &models.User{ID: 7, Email: "test@example.com", Role: models.Role{Name: models.StrUser}}
+
+	tokens, err := tokenService.IssueTokens(context.Background(), user)
+	assert.NoError(t, err)
+
+	// The password change bumped the version, the session must not be renewed
+	changedUser := *user
+	changedUser.TokenVersion++
+	loadUser := func(ctx context.Context, userID uint) (*models.User, error) {
+		return &changedUser, nil
+	}
+
+	_, err = tokenService.RefreshTokens(context.Background(), tokens.RefreshToken, loadUser)
+	assert.True(t, apperrors.Is(err, &apperrors.SessionRevokedErr))
+}
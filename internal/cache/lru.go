@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// lruCache is a bounded in-memory cache. Entries expire after their TTL and the
+// least recently used entry is evicted once the capacity is reached.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // Front is the most recently used entry
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+func (c *lruCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(element)
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *lruCache) set(key, value string, ttl time.Duration) {
+	if ttl <= 0 || c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(key, value, ttl)
+}
+
+// setNX sets the value unless a live entry exists for the key
+func (c *lruCache) setNX(key, value string, ttl time.Duration) bool {
+	if ttl <= 0 || c.capacity <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok && c.now().Before(element.Value.(*lruEntry).expiresAt) {
+		return false
+	}
+	c.setLocked(key, value, ttl)
+	return true
+}
+
+// setLocked stores the entry, the caller holds c.mu
+func (c *lruCache) setLocked(key, value string, ttl time.Duration) {
+	expiresAt := c.now().Add(ttl)
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// incr increments the counter stored at key, a new counter expires after ttl.
+// The counter is read and reset under one lock, concurrent calls can't both
+// start it at 1.
+func (c *lruCache) incr(key string, ttl time.Duration) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if ok && c.now().Before(element.Value.(*lruEntry).expiresAt) {
+		entry := element.Value.(*lruEntry)
+		count, _ := strconv.ParseInt(entry.value, 10, 64)
+		count++
+		entry.value = strconv.FormatInt(count, 10)
+		c.order.MoveToFront(element)
+		return count
+	}
+
+	if ttl > 0 && c.capacity > 0 {
+		c.setLocked(key, "1", ttl)
+	}
+	return 1
+}
+
+func (c *lruCache) delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.removeElement(element)
+		}
+	}
+}
+
+func (c *lruCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lruCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*lruEntry).key)
+}
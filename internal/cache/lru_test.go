@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	lru := newLRUCache(2)
+
+	lru.set("a", "1", time.Minute)
+	lru.set("b", "2", time.Minute)
+	// Reading "a" makes "b" the least recently used entry
+	_, ok := lru.get("a")
+	assert.True(t, ok)
+
+	lru.set("c", "3", time.Minute)
+	assert.Equal(t, 2, lru.len())
+
+	_, ok = lru.get("b")
+	assert.False(t, ok)
+	value, ok := lru.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+}
+
+func TestLRUCache_Expiration(t *testing.T) {
+	now := time.Now()
+	lru := newLRUCache(10)
+	lru.now = func() time.Time { return now }
+
+	lru.set("a", "1", time.Second)
+	_, ok := lru.get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = lru.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, lru.len())
+}
+
+func TestLRUCache_DeleteAndFlush(t *testing.T) {
+	lru := newLRUCache(10)
+	lru.set("a", "1", time.Minute)
+	lru.set("b", "2", time.Minute)
+	lru.set("c", "3", time.Minute)
+
+	lru.delete("a", "missing")
+	_, ok := lru.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 2, lru.len())
+
+	lru.flush()
+	assert.Equal(t, 0, lru.len())
+	_, ok = lru.get("b")
+	assert.False(t, ok)
+}
+
+func TestLRUCache_UpdateKeepsSingleEntry(t *testing.T) {
+	lru := newLRUCache(10)
+	lru.set("a", "1", time.Minute)
+	lru.set("a", "2", time.Minute)
+
+	value, ok := lru.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "2", value)
+	assert.Equal(t, 1, lru.len())
+}
+
+func TestLRUCache_ConcurrentIncr(t *testing.T) {
+	lru := newLRUCache(10)
+
+	// Every increment must count, also the ones racing to create the counter
+	const increments = 100
+	var wg sync.WaitGroup
+	for i := 0; i < increments; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lru.incr("a", time.Minute)
+		}()
+	}
+	wg.Wait()
+
+	value, ok := lru.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, strconv.Itoa(increments), value)
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// noExpiration stands for a zero TTL, which means "keep forever" in Redis
+const noExpiration = 100 * 365 * 24 * time.Hour
+
+// MemoryCache keeps everything in process memory. It stands in for Redis while
+// Redis can't be reached, so the state is per instance and bounded by size.
+type MemoryCache struct {
+	store *lruCache
+	mu    sync.Mutex
+	tags  map[string]map[string]struct{}
+}
+
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		store: newLRUCache(size),
+		tags:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string, cacheTTL time.Duration) (string, error) {
+	value, ok := c.store.get(key)
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value string, cacheTTL time.Duration) error {
+	c.store.set(key, value, ttlOrForever(cacheTTL))
+	return nil
+}
+
+func (c *MemoryCache) SetNX(ctx context.Context, key string, value string, cacheTTL time.Duration) (bool, error) {
+	return c.store.setNX(key, value, ttlOrForever(cacheTTL)), nil
+}
+
+func (c *MemoryCache) Incr(ctx context.Context, key string, cacheTTL time.Duration) (int64, error) {
+	return c.store.incr(key, ttlOrForever(cacheTTL)), nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.store.delete(keys...)
+	return nil
+}
+
+func (c *MemoryCache) Tag(ctx context.Context, key string, cacheTTL time.Duration, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+	return nil
+}
+
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.store.delete(key)
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+func ttlOrForever(cacheTTL time.Duration) time.Duration {
+	if cacheTTL <= 0 {
+		return noExpiration
+	}
+	return cacheTTL
+}
@@ -35,6 +35,25 @@ func (m *MockCacheInterface) EXPECT() *MockCacheInterfaceMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockCacheInterface) Delete(ctx context.Context, keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Delete", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCacheInterfaceMockRecorder) Delete(ctx interface{}, keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCacheInterface)(nil).Delete), varargs...)
+}
+
 // Get mocks base method.
 func (m *MockCacheInterface) Get(ctx context.Context, key string, cacheTTL time.Duration) (string, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +69,40 @@ func (mr *MockCacheInterfaceMockRecorder) Get(ctx, key, cacheTTL interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCacheInterface)(nil).Get), ctx, key, cacheTTL)
 }
 
+// Incr mocks base method.
+func (m *MockCacheInterface) Incr(ctx context.Context, key string, cacheTTL time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, cacheTTL)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockCacheInterfaceMockRecorder) Incr(ctx, key, cacheTTL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockCacheInterface)(nil).Incr), ctx, key, cacheTTL)
+}
+
+// InvalidateTags mocks base method.
+func (m *MockCacheInterface) InvalidateTags(ctx context.Context, tags ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range tags {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InvalidateTags", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateTags indicates an expected call of InvalidateTags.
+func (mr *MockCacheInterfaceMockRecorder) InvalidateTags(ctx interface{}, tags ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, tags...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTags", reflect.TypeOf((*MockCacheInterface)(nil).InvalidateTags), varargs...)
+}
+
 // Set mocks base method.
 func (m *MockCacheInterface) Set(ctx context.Context, key, value string, cacheTTL time.Duration) error {
 	m.ctrl.T.Helper()
@@ -63,3 +116,74 @@ func (mr *MockCacheInterfaceMockRecorder) Set(ctx, key, value, cacheTTL interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCacheInterface)(nil).Set), ctx, key, value, cacheTTL)
 }
+
+// SetNX mocks base method.
+func (m *MockCacheInterface) SetNX(ctx context.Context, key, value string, cacheTTL time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNX", ctx, key, value, cacheTTL)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX.
+func (mr *MockCacheInterfaceMockRecorder) SetNX(ctx, key, value, cacheTTL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockCacheInterface)(nil).SetNX), ctx, key, value, cacheTTL)
+}
+
+// Tag mocks base method.
+func (m *MockCacheInterface) Tag(ctx context.Context, key string, cacheTTL time.Duration, tags ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key, cacheTTL}
+	for _, a := range tags {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Tag", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Tag indicates an expected call of Tag.
+func (mr *MockCacheInterfaceMockRecorder) Tag(ctx, key, cacheTTL interface{}, tags ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key, cacheTTL}, tags...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tag", reflect.TypeOf((*MockCacheInterface)(nil).Tag), varargs...)
+}
+
+// MockPinger is a mock of Pinger interface.
+type MockPinger struct {
+	ctrl     *gomock.Controller
+	recorder *MockPingerMockRecorder
+}
+
+// MockPingerMockRecorder is the mock recorder for MockPinger.
+type MockPingerMockRecorder struct {
+	mock *MockPinger
+}
+
+// NewMockPinger creates a new mock instance.
+func NewMockPinger(ctrl *gomock.Controller) *MockPinger {
+	mock := &MockPinger{ctrl: ctrl}
+	mock.recorder = &MockPingerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPinger) EXPECT() *MockPingerMockRecorder {
+	return m.recorder
+}
+
+// Ping mocks base method.
+func (m *MockPinger) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockPingerMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockPinger)(nil).Ping), ctx)
+}
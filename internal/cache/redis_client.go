@@ -3,39 +3,77 @@ package cache
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+	"go.uber.org/zap"
+)
+
+const (
+	TypeRedis  = "redis"
+	TypeTiered = "tiered"
 )
 
 var ctx = context.Background()
 
+// ErrCacheMiss is returned by Get when the key is not present in the cache
+var ErrCacheMiss = errors.New("key does not exist")
+
 type CacheInterface interface {
 	Get(ctx context.Context, key string, cacheTTL time.Duration) (string, error)
 	Set(ctx context.Context, key string, value string, cacheTTL time.Duration) error
+	// SetNX stores the value only if the key does not exist yet and reports
+	// whether it did, concurrent callers can use it to claim a key once
+	SetNX(ctx context.Context, key string, value string, cacheTTL time.Duration) (bool, error)
+	Incr(ctx context.Context, key string, cacheTTL time.Duration) (int64, error)
+	Delete(ctx context.Context, keys ...string) error
+	// Tag records the key under each tag so that InvalidateTags can drop all
+	// keys of a tag at once
+	Tag(ctx context.Context, key string, cacheTTL time.Duration, tags ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+// Pinger is implemented by caches that can check their connection to Redis
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// NewCache returns the cache selected by CACHE_TYPE on top of the Redis client
+func NewCache(cfg *config.Config, redisClient *RedisClient, logger *zap.SugaredLogger) (CacheInterface, error) {
+	switch cfg.CacheType {
+	case TypeRedis, "":
+		return redisClient, nil
+	case TypeTiered:
+		return NewTieredCache(redisClient, cfg.CacheLocalSize, cfg.CacheLocalTTL, logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported CACHE_TYPE %q", cfg.CacheType)
+	}
 }
 
 type RedisClient struct {
 	Client *redis.Client
 }
 
-func NewRedisClient(redisURL string) *RedisClient {
+// NewRedisClient does not wait for Redis, the client connects on first use so the
+// service can start while Redis is down
+func NewRedisClient(redisURL string) (*RedisClient, error) {
 	// Parse the Redis URL
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
-		log.Fatalf("Could not parse Redis URL: %v", err)
+		return nil, fmt.Errorf("could not parse Redis URL: %w", err)
 	}
 
 	// Create a new Redis client using the parsed options
 	rdb := redis.NewClient(opt)
 
-	_, err = rdb.Ping(ctx).Result()
-	if err != nil {
-		log.Fatalf("Could not connect to Redis: %v", err)
-	}
+	return &RedisClient{Client: rdb}, nil
+}
 
-	return &RedisClient{Client: rdb}
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
 }
 
 // Get отримує значення за ключем з Redis
@@ -43,7 +81,7 @@ func (r *RedisClient) Get(ctx context.Context, key string, cacheTTL time.Duratio
 	val, err := r.Client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		// Key does not exist
-		return "", errors.New("key does not exist")
+		return "", ErrCacheMiss
 	} else if err != nil {
 		// Error during query execution
 		return "", err
@@ -60,3 +98,69 @@ func (r *RedisClient) Set(ctx context.Context, key string, value string, cacheTT
 	}
 	return nil
 }
+
+// SetNX stores the value unless the key already exists, in a single Redis command
+func (r *RedisClient) SetNX(ctx context.Context, key string, value string, cacheTTL time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, cacheTTL).Result()
+}
+
+// Incr atomically increments the counter stored at key. The TTL is set when the
+// counter is created, so the counter expires cacheTTL after the first increment.
+func (r *RedisClient) Incr(ctx context.Context, key string, cacheTTL time.Duration) (int64, error) {
+	val, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if val == 1 {
+		err = r.Client.Expire(ctx, key, cacheTTL).Err()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return val, nil
+}
+
+// Delete removes the keys, missing keys are ignored
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.Client.Del(ctx, keys...).Err()
+}
+
+// Tag adds the key to a Redis set per tag. The set lives as long as the key
+// last added to it, older members expire on their own in the meantime.
+func (r *RedisClient) Tag(ctx context.Context, key string, cacheTTL time.Duration, tags ...string) error {
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.SAdd(ctx, constants.CacheTagKeyPrefix+tag, key)
+			pipe.Expire(ctx, constants.CacheTagKeyPrefix+tag, cacheTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// InvalidateTags deletes every key recorded under the tags together with the tag sets
+func (r *RedisClient) InvalidateTags(ctx context.Context, tags ...string) error {
+	_, err := r.invalidateTags(ctx, tags...)
+	return err
+}
+
+// invalidateTags returns the deleted keys, also the ones deleted before an error
+func (r *RedisClient) invalidateTags(ctx context.Context, tags ...string) ([]string, error) {
+	var deleted []string
+	for _, tag := range tags {
+		tagKey := constants.CacheTagKeyPrefix + tag
+		keys, err := r.Client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return deleted, err
+		}
+		err = r.Client.Del(ctx, append(keys, tagKey)...).Err()
+		if err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, keys...)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned by Ping while Redis calls are suspended
+var ErrCircuitOpen = errors.New("redis calls suspended by the circuit breaker")
+
+// CircuitBreaker stops calling Redis after a number of consecutive failures. Once
+// the cooldown is over a single call is let through to find out whether Redis is
+// back, the client reconnects on that call.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+	lastErr   error
+	logger    *zap.SugaredLogger
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration, logger *zap.SugaredLogger) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+	}
+}
+
+// Status reports whether calls currently bypass Redis and the last Redis error
+func (b *CircuitBreaker) Status() (degraded bool, lastErr error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold, b.lastErr
+}
+
+// Trip opens the breaker right away, e.g. when Redis is down at startup
+func (b *CircuitBreaker) Trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = b.threshold
+	b.lastErr = err
+	b.openUntil = time.Now().Add(b.cooldown)
+}
+
+// allow reports whether the call may go to Redis and whether it is the probe
+// of an open breaker
+func (b *CircuitBreaker) allow() (allowed bool, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// release lets the next call probe again without recording an outcome
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		if b.failures >= b.threshold {
+			b.logger.Infof("Redis is available again, leaving degraded mode")
+		}
+		b.failures = 0
+		b.lastErr = nil
+		return
+	}
+
+	b.failures++
+	b.lastErr = err
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			b.logger.Warnf("Redis is unavailable, switching to degraded mode: %v", err)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// ResilientCache calls the remote cache with a timeout and falls back to another
+// cache, usually a MemoryCache, while the circuit breaker is open. Writes made
+// in degraded mode stay in the fallback and are not copied to Redis later.
+// Without a fallback the cache fails closed, calls that can't reach Redis
+// return apperrors.CacheUnavailableErr.
+type ResilientCache struct {
+	remote   CacheInterface
+	fallback CacheInterface
+	breaker  *CircuitBreaker
+	timeout  time.Duration
+}
+
+func NewResilientCache(remote CacheInterface, fallback CacheInterface, breaker *CircuitBreaker, timeout time.Duration) *ResilientCache {
+	return &ResilientCache{
+		remote:   remote,
+		fallback: fallback,
+		breaker:  breaker,
+		timeout:  timeout,
+	}
+}
+
+func (c *ResilientCache) Get(ctx context.Context, key string, cacheTTL time.Duration) (string, error) {
+	var value string
+	var err error
+	callErr := c.do(ctx, func(ctx context.Context) error {
+		value, err = c.remote.Get(ctx, key, cacheTTL)
+		if errors.Is(err, ErrCacheMiss) {
+			return nil
+		}
+		return err
+	})
+	if callErr == nil {
+		return value, err
+	}
+	if c.fallback == nil {
+		return "", unavailable(callErr)
+	}
+	return c.fallback.Get(ctx, key, cacheTTL)
+}
+
+func (c *ResilientCache) Set(ctx context.Context, key string, value string, cacheTTL time.Duration) error {
+	err := c.do(ctx, func(ctx context.Context) error {
+		return c.remote.Set(ctx, key, value, cacheTTL)
+	})
+	if err == nil {
+		return nil
+	}
+	if c.fallback == nil {
+		return unavailable(err)
+	}
+	return c.fallback.Set(ctx, key, value, cacheTTL)
+}
+
+func (c *ResilientCache) SetNX(ctx context.Context, key string, value string, cacheTTL time.Duration) (bool, error) {
+	var stored bool
+	err := c.do(ctx, func(ctx context.Context) (err error) {
+		stored, err = c.remote.SetNX(ctx, key, value, cacheTTL)
+		return err
+	})
+	if err == nil {
+		return stored, nil
+	}
+	if c.fallback == nil {
+		return false, unavailable(err)
+	}
+	return c.fallback.SetNX(ctx, key, value, cacheTTL)
+}
+
+func (c *ResilientCache) Incr(ctx context.Context, key string, cacheTTL time.Duration) (int64, error) {
+	var value int64
+	err := c.do(ctx, func(ctx context.Context) (err error) {
+		value, err = c.remote.Incr(ctx, key, cacheTTL)
+		return err
+	})
+	if err == nil {
+		return value, nil
+	}
+	if c.fallback == nil {
+		return 0, unavailable(err)
+	}
+	return c.fallback.Incr(ctx, key, cacheTTL)
+}
+
+func (c *ResilientCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.do(ctx, func(ctx context.Context) error {
+		return c.remote.Delete(ctx, keys...)
+	})
+	if err == nil {
+		return nil
+	}
+	if c.fallback == nil {
+		return unavailable(err)
+	}
+	return c.fallback.Delete(ctx, keys...)
+}
+
+func (c *ResilientCache) Tag(ctx context.Context, key string, cacheTTL time.Duration, tags ...string) error {
+	err := c.do(ctx, func(ctx context.Context) error {
+		return c.remote.Tag(ctx, key, cacheTTL, tags...)
+	})
+	if err == nil {
+		return nil
+	}
+	if c.fallback == nil {
+		return unavailable(err)
+	}
+	return c.fallback.Tag(ctx, key, cacheTTL, tags...)
+}
+
+func (c *ResilientCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	err := c.do(ctx, func(ctx context.Context) error {
+		return c.remote.InvalidateTags(ctx, tags...)
+	})
+	if err == nil {
+		return nil
+	}
+	if c.fallback == nil {
+		return unavailable(err)
+	}
+	return c.fallback.InvalidateTags(ctx, tags...)
+}
+
+// Ping checks the remote cache through the breaker. While the breaker is open
+// Redis is not called and the last Redis error is returned.
+func (c *ResilientCache) Ping(ctx context.Context) error {
+	pinger, ok := c.remote.(Pinger)
+	if !ok {
+		return nil
+	}
+
+	err := c.do(ctx, pinger.Ping)
+	if errors.Is(err, ErrCircuitOpen) {
+		if _, lastErr := c.breaker.Status(); lastErr != nil {
+			return lastErr
+		}
+	}
+	return err
+}
+
+// do runs the remote call unless the breaker is open and returns why it didn't
+// succeed. Requests cancelled by the client don't tell anything about Redis,
+// they neither count as a failure nor close the breaker.
+func (c *ResilientCache) do(ctx context.Context, call func(ctx context.Context) error) error {
+	allowed, probe := c.breaker.allow()
+	if !allowed {
+		return ErrCircuitOpen
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	err := call(callCtx)
+	if err != nil && ctx.Err() != nil {
+		if probe {
+			c.breaker.release()
+		}
+		return ctx.Err()
+	}
+	c.breaker.record(err)
+	return err
+}
+
+func unavailable(err error) error {
+	return apperrors.CacheUnavailableErr.AppendMessage(err)
+}
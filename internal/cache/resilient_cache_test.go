@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"go.uber.org/zap"
+)
+
+func TestResilientCache_FallsBackWhileBreakerIsOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	errRedisDown := errors.New("connection refused")
+	remote := NewMockCacheInterface(ctrl)
+	// The breaker opens after two failures, the third call never reaches Redis
+	remote.EXPECT().Set(gomock.Any(), "key", "value", time.Minute).Return(errRedisDown).Times(2)
+
+	breaker := NewCircuitBreaker(2, time.Hour, zap.NewNop().Sugar())
+	resilientCache := NewResilientCache(remote, NewMemoryCache(10), breaker, time.Second)
+
+	for i := 0; i < 3; i++ {
+		err := resilientCache.Set(context.Background(), "key", "value", time.Minute)
+		assert.NoError(t, err)
+	}
+
+	degraded, lastErr := breaker.Status()
+	assert.True(t, degraded)
+	assert.Equal(t, errRedisDown, lastErr)
+
+	value, err := resilientCache.Get(context.Background(), "key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestResilientCache_RecoversAfterCooldown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	remote := NewMockCacheInterface(ctrl)
+	remote.EXPECT().Get(gomock.Any(), "key", time.Minute).Return("", ErrCacheMiss)
+
+	breaker := NewCircuitBreaker(1, 0, zap.NewNop().Sugar())
+	breaker.Trip(errors.New("connection refused"))
+	resilientCache := NewResilientCache(remote, NewMemoryCache(10), breaker, time.Second)
+
+	// The probe reaches Redis, a miss is a healthy answer
+	_, err := resilientCache.Get(context.Background(), "key", time.Minute)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	degraded, lastErr := breaker.Status()
+	assert.False(t, degraded)
+	assert.NoError(t, lastErr)
+}
+
+func TestResilientCache_FailsClosedWithoutFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	remote := NewMockCacheInterface(ctrl)
+	remote.EXPECT().SetNX(gomock.Any(), "key", "used", time.Minute).Return(false, errors.New("connection refused"))
+
+	breaker := NewCircuitBreaker(1, time.Hour, zap.NewNop().Sugar())
+	resilientCache := NewResilientCache(remote, nil, breaker, time.Second)
+
+	_, err := resilientCache.SetNX(context.Background(), "key", "used", time.Minute)
+	assert.True(t, apperrors.Is(err, &apperrors.CacheUnavailableErr))
+
+	// The breaker is open now, Redis is not called and nothing is served from memory
+	_, err = resilientCache.Get(context.Background(), "key", time.Minute)
+	assert.True(t, apperrors.Is(err, &apperrors.CacheUnavailableErr))
+}
+
+func TestResilientCache_CancelledProbeKeepsBreakerOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	remote := NewMockCacheInterface(ctrl)
+	remote.EXPECT().Get(gomock.Any(), "key", time.Minute).DoAndReturn(
+		func(callCtx context.Context, key string, cacheTTL time.Duration) (string, error) {
+			cancel()
+			return "", callCtx.Err()
+		})
+	remote.EXPECT().Get(gomock.Any(), "key", time.Minute).Return("value", nil)
+
+	breaker := NewCircuitBreaker(1, 0, zap.NewNop().Sugar())
+	breaker.Trip(errors.New("connection refused"))
+	resilientCache := NewResilientCache(remote, NewMemoryCache(10), breaker, time.Second)
+
+	// The client gave up during the probe, that says nothing about Redis
+	_, err := resilientCache.Get(ctx, "key", time.Minute)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	degraded, _ := breaker.Status()
+	assert.True(t, degraded)
+
+	// The next call may probe again
+	value, err := resilientCache.Get(context.Background(), "key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	degraded, _ = breaker.Status()
+	assert.False(t, degraded)
+}
+
+func TestMemoryCache_IncrAndTags(t *testing.T) {
+	memoryCache := NewMemoryCache(10)
+	ctx := context.Background()
+
+	count, _ := memoryCache.Incr(ctx, "counter", time.Minute)
+	assert.Equal(t, int64(1), count)
+	count, _ = memoryCache.Incr(ctx, "counter", time.Minute)
+	assert.Equal(t, int64(2), count)
+
+	memoryCache.Set(ctx, "users:1", "list", time.Minute)
+	memoryCache.Tag(ctx, "users:1", time.Minute, "users_list")
+	memoryCache.InvalidateTags(ctx, "users_list")
+
+	_, err := memoryCache.Get(ctx, "users:1", time.Minute)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// InvalidationChannel is the Redis pub/sub channel instances use to tell each
+// other which keys changed
+const InvalidationChannel = "cache_invalidation"
+
+type invalidationMessage struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// TieredCache keeps recently read values in process memory in front of Redis.
+// Writes go to Redis and are announced on InvalidationChannel so that the other
+// instances drop their local copies. A lost message can't make an instance
+// serve stale data for longer than the local TTL.
+type TieredCache struct {
+	remote   *RedisClient
+	local    *lruCache
+	localTTL time.Duration
+	origin   string
+	logger   *zap.SugaredLogger
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewTieredCache(remote *RedisClient, localSize int, localTTL time.Duration, logger *zap.SugaredLogger) *TieredCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &TieredCache{
+		remote:   remote,
+		local:    newLRUCache(localSize),
+		localTTL: localTTL,
+		origin:   newInstanceID(),
+		logger:   logger,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go c.listen(ctx)
+	return c
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string, cacheTTL time.Duration) (string, error) {
+	if value, ok := c.local.get(key); ok {
+		return value, nil
+	}
+
+	// The remaining TTL comes in the same round trip, the local copy must not
+	// outlive the Redis key
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := c.remote.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	} else if err != nil {
+		return "", err
+	}
+
+	ttl := c.localTTL
+	if remaining := pttl.Val(); remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+	c.local.set(key, get.Val(), ttl)
+	return get.Val(), nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value string, cacheTTL time.Duration) error {
+	err := c.remote.Set(ctx, key, value, cacheTTL)
+	if err != nil {
+		c.local.delete(key)
+		return err
+	}
+
+	ttl := c.localTTL
+	if cacheTTL > 0 && cacheTTL < ttl {
+		ttl = cacheTTL
+	}
+	c.local.set(key, value, ttl)
+	return c.publish(ctx, key)
+}
+
+// SetNX is decided by Redis, the local copy is only dropped
+func (c *TieredCache) SetNX(ctx context.Context, key string, value string, cacheTTL time.Duration) (bool, error) {
+	c.local.delete(key)
+	ok, err := c.remote.SetNX(ctx, key, value, cacheTTL)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return true, c.publish(ctx, key)
+}
+
+// Incr is not cached locally, counters are only consistent in Redis
+func (c *TieredCache) Incr(ctx context.Context, key string, cacheTTL time.Duration) (int64, error) {
+	c.local.delete(key)
+	val, err := c.remote.Incr(ctx, key, cacheTTL)
+	if err != nil {
+		return 0, err
+	}
+	return val, c.publish(ctx, key)
+}
+
+func (c *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	c.local.delete(keys...)
+	err := c.remote.Delete(ctx, keys...)
+	if err != nil {
+		return err
+	}
+	return c.publish(ctx, keys...)
+}
+
+func (c *TieredCache) Tag(ctx context.Context, key string, cacheTTL time.Duration, tags ...string) error {
+	return c.remote.Tag(ctx, key, cacheTTL, tags...)
+}
+
+func (c *TieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	keys, err := c.remote.invalidateTags(ctx, tags...)
+	c.local.delete(keys...)
+	if err != nil {
+		return err
+	}
+	return c.publish(ctx, keys...)
+}
+
+func (c *TieredCache) Ping(ctx context.Context) error {
+	return c.remote.Ping(ctx)
+}
+
+// Close stops listening for invalidations of other instances
+func (c *TieredCache) Close() {
+	c.cancel()
+	<-c.done
+}
+
+func (c *TieredCache) publish(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	message, err := json.Marshal(&invalidationMessage{Origin: c.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return c.remote.Client.Publish(ctx, InvalidationChannel, message).Err()
+}
+
+// listen drops the keys other instances changed. go-redis resubscribes on its own
+// after a lost connection, the local cache is flushed then since messages may
+// have been missed in the meantime.
+func (c *TieredCache) listen(ctx context.Context) {
+	defer close(c.done)
+
+	pubsub := c.remote.Client.Subscribe(ctx, InvalidationChannel)
+	defer pubsub.Close()
+	// Receive doesn't watch the context, closing the subscription unblocks it
+	stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+	defer stop()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Warnf("Cache invalidation subscription failed: %v", err)
+			c.local.flush()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// (Re)subscribed, whatever was published in between is unknown
+			c.local.flush()
+		case *redis.Message:
+			var invalidation invalidationMessage
+			if json.Unmarshal([]byte(msg.Payload), &invalidation) != nil || invalidation.Origin == c.origin {
+				continue
+			}
+			c.local.delete(invalidation.Keys...)
+		}
+	}
+}
+
+func newInstanceID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// newTestTieredCache returns a tiered cache on top of the miniredis server once
+// it listens for invalidations
+func newTestTieredCache(t *testing.T, server *miniredis.Miniredis, localTTL time.Duration) *TieredCache {
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	subscribers := server.PubSubNumSub(InvalidationChannel)[InvalidationChannel]
+	tieredCache := NewTieredCache(&RedisClient{Client: client}, 10, localTTL, zap.NewNop().Sugar())
+	t.Cleanup(tieredCache.Close)
+
+	assert.Eventually(t, func() bool {
+		return server.PubSubNumSub(InvalidationChannel)[InvalidationChannel] > subscribers
+	}, 5*time.Second, 10*time.Millisecond)
+	return tieredCache
+}
+
+func TestTieredCache_InvalidatesOtherInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	writer := newTestTieredCache(t, server, time.Minute)
+	reader := newTestTieredCache(t, server, time.Minute)
+	ctx := context.Background()
+
+	assert.NoError(t, writer.Set(ctx, "key", "v1", 0))
+	value, err := reader.Get(ctx, "key", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", value)
+
+	// The reader keeps v1 locally until the writer announces the change
+	assert.NoError(t, writer.Set(ctx, "key", "v2", 0))
+	assert.Eventually(t, func() bool {
+		value, err := reader.Get(ctx, "key", 0)
+		return err == nil && value == "v2"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, writer.Delete(ctx, "key"))
+	assert.Eventually(t, func() bool {
+		_, err := reader.Get(ctx, "key", 0)
+		return err == ErrCacheMiss
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTieredCache_FlushesOnResubscribe(t *testing.T) {
+	server := miniredis.RunT(t)
+	tieredCache := newTestTieredCache(t, server, time.Minute)
+	ctx := context.Background()
+
+	assert.NoError(t, tieredCache.Set(ctx, "key", "v1", 0))
+	value, err := tieredCache.Get(ctx, "key", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", value)
+
+	// A change made while the connection was lost is never announced
+	server.Close()
+	assert.NoError(t, server.Set("key", "v2"))
+	assert.NoError(t, server.Restart())
+
+	assert.Eventually(t, func() bool {
+		value, err := tieredCache.Get(ctx, "key", 0)
+		return err == nil && value == "v2"
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestTieredCache_LocalTTL(t *testing.T) {
+	server := miniredis.RunT(t)
+	tieredCache := newTestTieredCache(t, server, time.Second)
+	now := time.Now()
+	tieredCache.local.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// The local copy is capped by the local TTL
+	assert.NoError(t, server.Set("key", "v1"))
+	_, err := tieredCache.Get(ctx, "key", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, server.Set("key", "v2"))
+
+	now = now.Add(500 * time.Millisecond)
+	value, _ := tieredCache.Get(ctx, "key", 0)
+	assert.Equal(t, "v1", value)
+
+	now = now.Add(time.Second)
+	value, _ = tieredCache.Get(ctx, "key", 0)
+	assert.Equal(t, "v2", value)
+
+	// ...and by the remaining TTL of the Redis key
+	assert.NoError(t, server.Set("short", "v1"))
+	server.SetTTL("short", 100*time.Millisecond)
+	_, err = tieredCache.Get(ctx, "short", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, server.Set("short", "v2"))
+
+	now = now.Add(200 * time.Millisecond)
+	value, _ = tieredCache.Get(ctx, "short", 0)
+	assert.Equal(t, "v2", value)
+}
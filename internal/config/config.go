@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -12,7 +13,87 @@ type Config struct {
 	AppPort     string `required:"true" split_words:"true"`
 	PostgresURI string `required:"true" split_words:"true"`
 	RedisURL    string `required:"true" split_words:"true"`
-	JwtKey      string `required:"true" split_words:"true"`
+	JwtKey      string `split_words:"true"`
+
+	// JwtAlgorithm is one of HS256, RS256 or EdDSA. HS256 signs with JwtKey,
+	// the asymmetric algorithms sign with JwtPrivateKeys[JwtActiveKeyID].
+	JwtAlgorithm   string            `split_words:"true" default:"HS256"`
+	JwtActiveKeyID string            `split_words:"true"`
+	JwtPrivateKeys map[string]string `split_words:"true"` // kid:path to PEM file
+	JwtPublicKeys  map[string]string `split_words:"true"` // kid:path to PEM file, verify-only keys
+
+	AccessTokenTTL  time.Duration `split_words:"true" default:"15m"`
+	RefreshTokenTTL time.Duration `split_words:"true" default:"720h"`
+
+	// Login throttling: failed attempts are counted per email and per client IP
+	// within LoginAttemptWindow. Every failure delays the next attempt for the email
+	// exponentially, LoginMaxAttempts failures lock the account for LoginLockoutDuration.
+	LoginMaxAttempts     int           `split_words:"true" default:"5"`
+	LoginIPMaxAttempts   int           `envconfig:"LOGIN_IP_MAX_ATTEMPTS" default:"50"`
+	LoginAttemptWindow   time.Duration `split_words:"true" default:"15m"`
+	LoginLockoutDuration time.Duration `split_words:"true" default:"15m"`
+	LoginBackoffBase     time.Duration `split_words:"true" default:"1s"`
+	LoginBackoffMax      time.Duration `split_words:"true" default:"1m"`
+	// TrustProxyHeaders makes X-Forwarded-For the source of the client IP
+	TrustProxyHeaders bool `split_words:"true" default:"false"`
+
+	// CacheType is redis, or tiered to keep hot entries in process memory in front of Redis
+	CacheType      string        `split_words:"true" default:"redis"`
+	CacheLocalSize int           `split_words:"true" default:"10000"` // Max entries held in memory per instance
+	CacheLocalTTL  time.Duration `split_words:"true" default:"10s"`   // Max time an entry is served from memory
+	// CacheStaleWhileRevalidate is how long an expired cached response may still be
+	// served while a single background request refreshes it, 0 disables it
+	CacheStaleWhileRevalidate time.Duration `split_words:"true" default:"0s"`
+	// After CacheBreakerThreshold consecutive Redis failures the cache is served
+	// from process memory, Redis is tried again every CacheBreakerCooldown
+	CacheOpTimeout        time.Duration `split_words:"true" default:"250ms"`
+	CacheBreakerThreshold int           `split_words:"true" default:"5"`
+	CacheBreakerCooldown  time.Duration `split_words:"true" default:"10s"`
+
+	// MigrateOnStart applies pending migrations before serving, instances started
+	// together wait for each other on an advisory lock
+	MigrateOnStart bool `split_words:"true" default:"true"`
+
+	// HealthCheckTimeout bounds each /readyz dependency check
+	HealthCheckTimeout time.Duration `split_words:"true" default:"2s"`
+	// ShutdownReadinessDelay is how long /readyz fails after a stop signal before
+	// the server stops, so load balancers have time to take the instance out
+	ShutdownReadinessDelay time.Duration `split_words:"true" default:"5s"`
+	// ShutdownTimeout is how long in-flight requests may take to finish after that
+	ShutdownTimeout time.Duration `split_words:"true" default:"30s"`
+
+	HTTPReadHeaderTimeout time.Duration `envconfig:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	HTTPReadTimeout       time.Duration `envconfig:"HTTP_READ_TIMEOUT" default:"15s"`
+	HTTPWriteTimeout      time.Duration `envconfig:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	HTTPIdleTimeout       time.Duration `envconfig:"HTTP_IDLE_TIMEOUT" default:"2m"`
+
+	// LogMode is development (console output) or production (JSON), LogLevel
+	// overrides the level of the mode, e.g. debug or warn
+	LogMode  string `split_words:"true" default:"development"`
+	LogLevel string `split_words:"true"`
+
+	// TracingExporter is otlp, stdout or none
+	TracingExporter     string  `split_words:"true" default:"none"`
+	TracingServiceName  string  `split_words:"true" default:"web-layout"`
+	TracingOTLPEndpoint string  `envconfig:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+	TracingOTLPInsecure bool    `envconfig:"TRACING_OTLP_INSECURE" default:"true"`
+	TracingSampleRatio  float64 `split_words:"true" default:"1"` // Share of new traces recorded, incoming sampled traces are always recorded
+
+	// AppBaseURL is used to build links sent by email
+	AppBaseURL    string `split_words:"true" default:"http://localhost:50052"`
+	MailerType    string `split_words:"true" default:"file"` // file or smtp
+	MailerFileDir string `split_words:"true" default:"./mail"`
+	SMTPAddr      string `envconfig:"SMTP_ADDR" default:"localhost:1025"`
+	MailFrom      string `split_words:"true" default:"no-reply@example.com"`
+
+	EmailVerificationTTL         time.Duration `split_words:"true" default:"24h"`
+	EmailVerificationForLogin    bool          `split_words:"true" default:"false"`
+	EmailVerificationForVoting   bool          `split_words:"true" default:"true"`
+	EmailVerificationResendDelay time.Duration `split_words:"true" default:"1m"`
+
+	PasswordResetTTL time.Duration `split_words:"true" default:"1h"`
+	// PasswordHistorySize is how many recent passwords, the current one included, can't be reused
+	PasswordHistorySize int `split_words:"true" default:"5"`
 }
 
 func NewConfig() (*Config, error) {
@@ -1 +1,31 @@
 package constants
+
+// Cache key prefixes for the token state kept in Redis
+const (
+	RefreshTokenKeyPrefix     = "refresh_token:"
+	UsedRefreshTokenKeyPrefix = "used_refresh_token:"
+	TokenFamilyKeyPrefix      = "token_family:"
+
+	LoginFailuresEmailKeyPrefix = "login_failures_email:"
+	LoginFailuresIPKeyPrefix    = "login_failures_ip:"
+	LoginBackoffKeyPrefix       = "login_backoff:"
+	LoginLockKeyPrefix          = "login_lock:"
+
+	UsedVerificationTokenKeyPrefix = "used_verification_token:"
+	VerificationResendKeyPrefix    = "verification_resend:"
+)
+
+// Keys and tags of the cached HTTP responses
+const (
+	UserCacheKeyPrefix = "user:"
+	CacheTagKeyPrefix  = "cache_tag:"
+
+	UsersListCacheTag  = "users_list"
+	UsersCountCacheTag = "users_count"
+)
+
+const (
+	TokenStatusActive  = "active"
+	TokenStatusUsed    = "used"
+	TokenStatusRevoked = "revoked"
+)
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/jkozhemiaka/web-layout/internal/database/migrations"
+)
+
+func TestLoadMigrations_PairsFilesInVersionOrder(t *testing.T) {
+	source := fstest.MapFS{
+		"0010_add_index.up.sql":   {Data: []byte("CREATE INDEX")},
+		"0002_add_table.up.sql":   {Data: []byte("CREATE TABLE")},
+		"0002_add_table.down.sql": {Data: []byte("DROP TABLE")},
+		"README.md":               {Data: []byte("not a migration")},
+	}
+
+	loaded, err := loadMigrations(source)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 2, Name: "add_table", Up: "CREATE TABLE", Down: "DROP TABLE"},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX"},
+	}, loaded)
+}
+
+func TestLoadMigrations_RejectsInvalidSets(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing up file": {
+			"0001_baseline.down.sql": {Data: []byte("DROP TABLE")},
+		},
+		"two names for one version": {
+			"0001_baseline.up.sql": {Data: []byte("CREATE TABLE")},
+			"0001_other.down.sql":  {Data: []byte("DROP TABLE")},
+		},
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadMigrations(source)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadMigrations_EmbeddedBaseline(t *testing.T) {
+	loaded, err := loadMigrations(migrations.FS)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	assert.Equal(t, "baseline", loaded[0].Name)
+	assert.NotEmpty(t, loaded[0].Down)
+}
+
+func TestCreateMigration_UsesNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0003_baseline.up.sql"), []byte("SELECT 1"), 0o644))
+
+	paths, err := CreateMigration(dir, "add_nickname")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "0004_add_nickname.up.sql"),
+		filepath.Join(dir, "0004_add_nickname.down.sql"),
+	}, paths)
+	_, err = CreateMigration(dir, "bad name")
+	assert.Error(t, err)
+}
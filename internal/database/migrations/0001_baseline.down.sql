@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS votes;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS password_history;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,116 @@
+-- Baseline schema, formerly init.sql. Every statement is idempotent so it can
+-- also be applied to a database that was created by init.sql.
+
+-- Create roles table
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL
+);
+
+-- Insert default roles
+INSERT INTO roles (name) VALUES ('user'), ('moderator'), ('admin') ON CONFLICT DO NOTHING;
+
+-- Create permissions table
+CREATE TABLE IF NOT EXISTS permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL
+);
+
+-- Create role to permission mapping
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INTEGER REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+-- Insert default permissions
+INSERT INTO permissions (name) VALUES
+    ('users:delete'),
+    ('users:update:any'),
+    ('users:update:role'),
+    ('votes:moderate'),
+    ('roles:manage')
+ON CONFLICT DO NOTHING;
+
+-- Moderators can moderate votes, admins can do everything
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE (r.name = 'moderator' AND p.name = 'votes:moderate') OR r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    role_id INT REFERENCES roles(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    vote_updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    rating INT NOT NULL DEFAULT 0,
+    token_version INT NOT NULL DEFAULT 0,
+    version INT NOT NULL DEFAULT 1,
+    email_verified_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Columns added after the first init.sql, which created the table without them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Create password reset tokens table, only token hashes are stored
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create password history table with previous password hashes
+CREATE TABLE IF NOT EXISTS password_history (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id);
+
+-- Create audit log table
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id SERIAL PRIMARY KEY,
+    actor_id INTEGER,
+    target_user_id INTEGER,
+    action VARCHAR(100) NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_user_id ON audit_logs (target_user_id);
+
+-- Create votes table
+CREATE TABLE IF NOT EXISTS votes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    profile_id INTEGER REFERENCES users(id),
+    value INTEGER NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, profile_id)
+);
+
+-- Set default role for existing users
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'user') WHERE role_id IS NULL;
+
+-- Insert a user with the 'admin' role
+INSERT INTO users (email, first_name, last_name, password, role_id, email_verified_at)
+VALUES (
+    'admin@example.com',
+    'Admin',
+    'Super',
+    '$2a$14$4Cxw5/NK2ARnNMcE8/jnSuo6vATld5cO1yxSuWXwniqgIJIa39I7a',  -- It's best to hash passwords before inserting them in a real application
+    (SELECT id FROM roles WHERE name = 'admin'),
+    CURRENT_TIMESTAMP
+) ON CONFLICT (email) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Brings the seeded account back unless its email has been taken since
+UPDATE users
+SET deleted_at = NULL
+WHERE email = 'admin@example.com'
+  AND password = '$2a$14$4Cxw5/NK2ARnNMcE8/jnSuo6vATld5cO1yxSuWXwniqgIJIa39I7a'
+  AND deleted_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM users live WHERE live.email = 'admin@example.com' AND live.deleted_at IS NULL);
@@ -0,0 +1,8 @@
+-- The baseline seeds admin@example.com with a password published in this
+-- repository. Deactivate it unless an operator has changed the password, the
+-- first administrator is created with the user create command instead.
+UPDATE users
+SET deleted_at = CURRENT_TIMESTAMP, token_version = token_version + 1
+WHERE email = 'admin@example.com'
+  AND password = '$2a$14$4Cxw5/NK2ARnNMcE8/jnSuo6vATld5cO1yxSuWXwniqgIJIa39I7a'
+  AND deleted_at IS NULL;
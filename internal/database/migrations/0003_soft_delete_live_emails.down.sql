@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_users_email_live;
+-- Fails while a deleted and a live user share an email
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
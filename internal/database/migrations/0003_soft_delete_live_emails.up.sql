@@ -0,0 +1,7 @@
+-- Live users used to carry a zero deleted_at instead of NULL
+UPDATE users SET deleted_at = NULL WHERE deleted_at < '1900-01-01';
+
+-- An email is unique among live users only, deleted accounts don't reserve it
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_live ON users (email) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'users:privacy';
+DROP TABLE IF EXISTS erasure_receipts;
+DROP FUNCTION IF EXISTS reject_erasure_receipt_change();
@@ -0,0 +1,28 @@
+-- Receipts of personal data erasures, kept for good
+CREATE TABLE IF NOT EXISTS erasure_receipts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER UNIQUE NOT NULL,
+    actor_id INTEGER NOT NULL,
+    votes_cast_removed INTEGER NOT NULL,
+    votes_received_removed INTEGER NOT NULL,
+    profiles_recalculated INTEGER NOT NULL,
+    erased_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION reject_erasure_receipt_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'erasure receipts are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS erasure_receipts_immutable ON erasure_receipts;
+CREATE TRIGGER erasure_receipts_immutable
+    BEFORE UPDATE OR DELETE ON erasure_receipts
+    FOR EACH ROW EXECUTE FUNCTION reject_erasure_receipt_change();
+
+-- Exporting and erasing the data of other users is up to administrators
+INSERT INTO permissions (name) VALUES ('users:privacy') ON CONFLICT DO NOTHING;
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'users:privacy'
+ON CONFLICT DO NOTHING;
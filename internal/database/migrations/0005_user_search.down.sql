@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_role_id;
+DROP INDEX IF EXISTS idx_users_rating;
+DROP INDEX IF EXISTS idx_users_created_at;
+DROP INDEX IF EXISTS idx_users_search_trgm;
+-- pg_trgm is left installed, other objects may depend on it
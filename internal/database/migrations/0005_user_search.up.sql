@@ -0,0 +1,10 @@
+-- Trigram index for the q search of GET /users, the expression must match the
+-- one of UserRepo.ListUsers
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users
+    USING gin ((first_name || ' ' || last_name || ' ' || email) gin_trgm_ops);
+
+-- Filters and sort orders of the user list
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at);
+CREATE INDEX IF NOT EXISTS idx_users_rating ON users (rating);
+CREATE INDEX IF NOT EXISTS idx_users_role_id ON users (role_id);
@@ -0,0 +1,2 @@
+-- Backfilled timestamps can't be told apart from real verifications, they stay
+SELECT 1;
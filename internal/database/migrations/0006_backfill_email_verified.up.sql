@@ -0,0 +1,4 @@
+-- Accounts created before email verification existed never got a verification
+-- email, treat them as verified so EMAIL_VERIFICATION_FOR_VOTING doesn't lock
+-- them out of voting
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
@@ -0,0 +1,8 @@
+// Package migrations holds the SQL migrations of the schema. Each version has an
+// up and a down file named {version}_{name}.up.sql and {version}_{name}.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"go.uber.org/zap"
 )
@@ -19,19 +21,28 @@ func NewBaseHandler(logger *zap.SugaredLogger) *BaseHandler {
 	}
 }
 
+// sendError does not log, the service layer has logged the error already and the
+// status ends up in the access log
 func (h *BaseHandler) sendError(w http.ResponseWriter, err error, httpStatus int) {
-	h.logger.Error(err.Error())
 	h.respond(w, &ErrorResponse{Message: err.Error()}, httpStatus)
 }
 
+// log returns the logger of the request, tagged with its request ID and user
+func (h *BaseHandler) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, h.logger)
+}
+
 func (h *BaseHandler) decode(r *http.Request, v interface{}) error {
 	return json.NewDecoder(r.Body).Decode(v)
 }
 
 func (h *BaseHandler) respond(w http.ResponseWriter, data interface{}, httpStatus int) {
-	w.WriteHeader(httpStatus)
+	// Headers set after WriteHeader are ignored
 	if data != nil {
 		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(httpStatus)
+	if data != nil {
 		json.NewEncoder(w).Encode(data)
 	}
 }
@@ -45,3 +56,17 @@ func (h *BaseHandler) GetAuthenticatedRole(ctx context.Context) string {
 	role, _ := ctx.Value(models.RoleContextKey).(string)
 	return role
 }
+
+// HasPermission reports whether the role of the authenticated user grants the permission
+func (h *BaseHandler) HasPermission(ctx context.Context, permission string) bool {
+	return models.HasPermission(ctx, permission)
+}
+
+// errorStatus returns the HTTP code carried by an AppError or the fallback
+func (h *BaseHandler) errorStatus(err error, fallback int) int {
+	appErr, ok := err.(*apperrors.AppError)
+	if !ok || appErr.HTTPCode == 0 {
+		return fallback
+	}
+	return appErr.HTTPCode
+}
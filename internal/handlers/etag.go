@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// UserETag returns the strong entity tag of the stored version of the user
+func UserETag(user *models.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+// ETagMatches reports whether an If-None-Match header matches the tag. The weak
+// comparison is used, as RFC 9110 requires for If-None-Match.
+func ETagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedVersion turns If-Match into the version the write must apply to.
+// 0 means the request is unconditional.
+func (h *userHandler) expectedVersion(ctx context.Context, r *http.Request, userID string) (uint, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	// If-Match uses the strong comparison, weak tags never match
+	var versions []uint
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			continue
+		}
+		version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 64)
+		if err == nil && version > 0 {
+			versions = append(versions, uint(version))
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, &apperrors.PreconditionFailedErr
+	case 1:
+		return versions[0], nil
+	}
+
+	// Several tags, the write has to apply to whichever one is current
+	user, err := h.userService.GetUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		if version == user.Version {
+			return version, nil
+		}
+	}
+	return 0, &apperrors.PreconditionFailedErr
+}
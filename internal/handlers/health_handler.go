@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusDegraded     = "degraded"
+	HealthStatusDown         = "down"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// DependencyCheck checks one dependency. A failing critical dependency makes the
+// service not ready, any other one only degrades it.
+type DependencyCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type HealthCheck struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+type healthHandler struct {
+	*BaseHandler
+	checks []DependencyCheck
+	// shuttingDown reports whether the server is draining its traffic
+	shuttingDown func() bool
+	logger       *zap.SugaredLogger
+	cfg          *config.Config
+}
+
+func NewHealthHandler(checks []DependencyCheck, shuttingDown func() bool, logger *zap.SugaredLogger, cfg *config.Config) *healthHandler {
+	return &healthHandler{
+		BaseHandler:  NewBaseHandler(logger),
+		checks:       checks,
+		shuttingDown: shuttingDown,
+		logger:       logger,
+		cfg:          cfg,
+	}
+}
+
+// Live answers as long as the process is able to serve requests
+func (h *healthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	h.respond(w, &HealthResponse{Status: HealthStatusOK}, http.StatusOK)
+}
+
+// Ready runs the dependency checks concurrently. It answers 503 when a critical
+// dependency fails or the server is shutting down, so load balancers stop
+// sending traffic to this instance.
+func (h *healthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if h.shuttingDown() {
+		h.respond(w, &HealthResponse{Status: HealthStatusShuttingDown}, http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.cfg.HealthCheckTimeout)
+	defer cancel()
+
+	results := make([]HealthCheck, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func(i int, check DependencyCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := &HealthResponse{
+		Status: HealthStatusOK,
+		Checks: make(map[string]HealthCheck, len(h.checks)),
+	}
+	httpStatus := http.StatusOK
+	for i, check := range h.checks {
+		result := results[i]
+		response.Checks[check.Name] = result
+
+		switch {
+		case result.Status == HealthStatusDown:
+			response.Status = HealthStatusDown
+			httpStatus = http.StatusServiceUnavailable
+		case result.Status == HealthStatusDegraded && response.Status == HealthStatusOK:
+			response.Status = HealthStatusDegraded
+		}
+	}
+
+	h.respond(w, response, httpStatus)
+}
+
+func runCheck(ctx context.Context, check DependencyCheck) HealthCheck {
+	start := time.Now()
+	err := check.Check(ctx)
+	result := HealthCheck{
+		Status:    HealthStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = HealthStatusDegraded
+		if check.Critical {
+			result.Status = HealthStatusDown
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+func okCheck(ctx context.Context) error { return nil }
+
+func failingCheck(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name         string
+		checks       []DependencyCheck
+		shuttingDown bool
+		wantCode     int
+		wantStatus   string
+	}{
+		{
+			name:       "All dependencies up",
+			checks:     []DependencyCheck{{Name: "postgres", Critical: true, Check: okCheck}, {Name: "redis", Check: okCheck}},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusOK,
+		},
+		{
+			name:       "Redis down only degrades",
+			checks:     []DependencyCheck{{Name: "postgres", Critical: true, Check: okCheck}, {Name: "redis", Check: failingCheck}},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusDegraded,
+		},
+		{
+			name:       "Postgres down",
+			checks:     []DependencyCheck{{Name: "postgres", Critical: true, Check: failingCheck}, {Name: "redis", Check: failingCheck}},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatusDown,
+		},
+		{
+			name:         "Shutting down",
+			checks:       []DependencyCheck{{Name: "postgres", Critical: true, Check: okCheck}},
+			shuttingDown: true,
+			wantCode:     http.StatusServiceUnavailable,
+			wantStatus:   HealthStatusShuttingDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shuttingDown := func() bool { return tt.shuttingDown }
+			handler := NewHealthHandler(tt.checks, shuttingDown, zap.NewExample().Sugar(), &config.Config{HealthCheckTimeout: time.Second})
+
+			w := httptest.NewRecorder()
+			handler.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var response HealthResponse
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantStatus, response.Status)
+			if !tt.shuttingDown {
+				assert.Len(t, response.Checks, len(tt.checks))
+			}
+		})
+	}
+}
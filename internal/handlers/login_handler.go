@@ -1,35 +1,62 @@
 package handlers
 
 import (
+	"context"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
 	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"gitlab.com/jkozhemiaka/web-layout/internal/services"
 	"go.uber.org/zap"
 )
 
 type loginHandler struct {
 	*BaseHandler
-	userService services.UserServiceInterface
-	logger      *zap.SugaredLogger
-	cfg         *config.Config
+	userService    services.UserServiceInterface
+	tokenService   auth.TokenServiceInterface
+	loginThrottler auth.LoginThrottlerInterface
+	logger         *zap.SugaredLogger
+	cfg            *config.Config
 }
 
-func NewLoginHandler(userService services.UserServiceInterface, logger *zap.SugaredLogger, cfg *config.Config) *loginHandler {
+func NewLoginHandler(userService services.UserServiceInterface, tokenService auth.TokenServiceInterface, loginThrottler auth.LoginThrottlerInterface, logger *zap.SugaredLogger, cfg *config.Config) *loginHandler {
 	return &loginHandler{
-		BaseHandler: NewBaseHandler(logger),
-		userService: userService,
-		logger:      logger,
-		cfg:         cfg,
+		BaseHandler:    NewBaseHandler(logger),
+		userService:    userService,
+		tokenService:   tokenService,
+		loginThrottler: loginThrottler,
+		logger:         logger,
+		cfg:            cfg,
 	}
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 func (h *loginHandler) Login(w http.ResponseWriter, r *http.Request) {
 	email := r.FormValue("email")
 	password := r.FormValue("password")
+	ctx := r.Context()
+	ip := h.clientIP(r)
+
+	// Refuse throttled attempts before spending time on bcrypt
+	retryAfter, err := h.loginThrottler.Check(ctx, email, ip)
+	if err != nil {
+		h.sendThrottled(w, err, retryAfter)
+		return
+	}
 
-	user, err := h.userService.GetUserByEmail(r.Context(), email)
+	user, err := h.userService.GetUserByEmail(ctx, email)
 	if err != nil {
 		h.sendError(w, err, http.StatusInternalServerError)
 		return
@@ -37,8 +64,122 @@ func (h *loginHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	err = auth.Access(email, password, user)
 	if err != nil {
+		if throttleErr := h.loginThrottler.RegisterFailure(ctx, email, ip); throttleErr != nil {
+			h.log(ctx).Error(throttleErr)
+		}
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	w.Write(auth.GenerateTokenHandler(email, user.Role.Name, user.ID, []byte(h.cfg.JwtKey)))
+
+	if err := h.loginThrottler.RegisterSuccess(ctx, email); err != nil {
+		h.log(ctx).Error(err)
+	}
+
+	if h.cfg.EmailVerificationForLogin && user.EmailVerifiedAt == nil {
+		h.sendError(w, &apperrors.EmailNotVerifiedErr, http.StatusForbidden)
+		return
+	}
+
+	tokens, err := h.tokenService.IssueTokens(r.Context(), user)
+	if err != nil {
+		h.log(ctx).Error(err)
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, tokens, http.StatusOK)
+}
+
+func (h *loginHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	refreshTokenRequest := &RefreshTokenRequest{}
+	err := h.decode(r, refreshTokenRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.tokenService.RefreshTokens(r.Context(), refreshTokenRequest.RefreshToken, h.loadUser)
+	if err != nil {
+		h.sendError(w, err, h.tokenErrorStatus(err))
+		return
+	}
+
+	h.respond(w, tokens, http.StatusOK)
+}
+
+func (h *loginHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	refreshTokenRequest := &RefreshTokenRequest{}
+	err := h.decode(r, refreshTokenRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.tokenService.RevokeRefreshToken(r.Context(), refreshTokenRequest.RefreshToken)
+	if err != nil {
+		h.sendError(w, err, h.tokenErrorStatus(err))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
+
+func (h *loginHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.respond(w, h.tokenService.JWKS(), http.StatusOK)
+}
+
+// UnlockAccount lifts the login lockout of the user
+func (h *loginHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userService.GetUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.sendError(w, err, http.StatusNotFound)
+		return
+	}
+
+	err = h.loginThrottler.Unlock(r.Context(), user.Email)
+	if err != nil {
+		h.log(r.Context()).Error(err)
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
+
+func (h *loginHandler) sendThrottled(w http.ResponseWriter, err error, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	h.sendError(w, err, h.errorStatus(err, http.StatusTooManyRequests))
+}
+
+// tokenErrorStatus answers 401 for anything wrong with the refresh token, only
+// an unreachable token store is reported as such
+func (h *loginHandler) tokenErrorStatus(err error) int {
+	if apperrors.Is(err, &apperrors.CacheUnavailableErr) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusUnauthorized
+}
+
+// clientIP returns the address of the client, X-Forwarded-For is only used behind a trusted proxy
+func (h *loginHandler) clientIP(r *http.Request) string {
+	if h.cfg.TrustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (h *loginHandler) loadUser(ctx context.Context, userID uint) (*models.User, error) {
+	return h.userService.GetUser(ctx, strconv.FormatUint(uint64(userID), 10))
 }
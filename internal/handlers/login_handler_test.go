@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"go.uber.org/zap"
+)
+
+func newLoginRequest(email, password string) *http.Request {
+	form := url.Values{"email": {email}, "password": {password}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestLogin_Locked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockThrottler := auth.NewMockLoginThrottlerInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	cfg := &config.Config{}
+
+	handler := NewLoginHandler(mockUserService, mockTokenService, mockThrottler, logger, cfg)
+
+	// The password is never checked for a locked account
+	mockThrottler.EXPECT().Check(gomock.Any(), "test@example.com", gomock.Any()).Return(90*time.Second, &apperrors.AccountLockedErr)
+
+	w := httptest.NewRecorder()
+	handler.Login(w, newLoginRequest("test@example.com", "password@123"))
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusLocked, res.StatusCode)
+	assert.Equal(t, "90", res.Header.Get("Retry-After"))
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockThrottler := auth.NewMockLoginThrottlerInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	cfg := &config.Config{}
+
+	handler := NewLoginHandler(mockUserService, mockTokenService, mockThrottler, logger, cfg)
+
+	mockThrottler.EXPECT().Check(gomock.Any(), "test@example.com", "192.0.2.1").Return(time.Duration(0), nil)
+	mockUserService.EXPECT().GetUserByEmail(gomock.Any(), "test@example.com").Return(nil, nil)
+	mockThrottler.EXPECT().RegisterFailure(gomock.Any(), "test@example.com", "192.0.2.1").Return(nil)
+
+	w := httptest.NewRecorder()
+	handler.Login(w, newLoginRequest("test@example.com", "password@123"))
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestRefreshToken_CacheUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockThrottler := auth.NewMockLoginThrottlerInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	cfg := &config.Config{}
+
+	handler := NewLoginHandler(mockUserService, mockTokenService, mockThrottler, logger, cfg)
+
+	// Without Redis the token can't be checked, that is not the client's fault
+	mockTokenService.EXPECT().RefreshTokens(gomock.Any(), "refresh", gomock.Any()).
+		Return(nil, apperrors.CacheUnavailableErr.AppendMessage("connection refused"))
+
+	req := httptest.NewRequest(http.MethodPost, "/refresh", strings.NewReader(`{"refresh_token": "refresh"}`))
+	w := httptest.NewRecorder()
+	handler.RefreshToken(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"go.uber.org/zap"
+)
+
+type passwordHandler struct {
+	*BaseHandler
+	passwordService services.PasswordServiceInterface
+	logger          *zap.SugaredLogger
+	validator       *validator.Validate
+	cfg             *config.Config
+}
+
+func NewPasswordHandler(passwordService services.PasswordServiceInterface, logger *zap.SugaredLogger, validator *validator.Validate, cfg *config.Config) *passwordHandler {
+	return &passwordHandler{
+		BaseHandler:     NewBaseHandler(logger),
+		passwordService: passwordService,
+		logger:          logger,
+		validator:       validator,
+		cfg:             cfg,
+	}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,password"`
+}
+
+// ChangePasswordRequest needs the current password only when users change their own one
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,password"`
+}
+
+func (h *passwordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	forgotPasswordRequest := &ForgotPasswordRequest{}
+	err := h.decode(r, forgotPasswordRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.validator.Struct(forgotPasswordRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Failures are only logged, the answer must not depend on the email being registered
+	err = h.passwordService.RequestPasswordReset(r.Context(), forgotPasswordRequest.Email)
+	if err != nil {
+		h.log(r.Context()).Error(err)
+	}
+
+	h.respond(w, nil, http.StatusAccepted)
+}
+
+func (h *passwordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	resetPasswordRequest := &ResetPasswordRequest{}
+	err := h.decode(r, resetPasswordRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.validator.Struct(resetPasswordRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.passwordService.ResetPassword(r.Context(), resetPasswordRequest.Token, resetPasswordRequest.Password)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
+
+func (h *passwordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+	actorID, err := strconv.ParseUint(ctx.Value(models.IDContextKey).(string), 10, 64)
+	if err != nil {
+		h.sendError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	changePasswordRequest := &ChangePasswordRequest{}
+	err = h.decode(r, changePasswordRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.validator.Struct(changePasswordRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.passwordService.ChangePassword(ctx, uint(actorID), uint(userID), changePasswordRequest.CurrentPassword, changePasswordRequest.NewPassword)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
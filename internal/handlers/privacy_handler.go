@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"go.uber.org/zap"
+)
+
+type privacyHandler struct {
+	*BaseHandler
+	privacyService services.PrivacyServiceInterface
+	logger         *zap.SugaredLogger
+	cfg            *config.Config
+}
+
+func NewPrivacyHandler(privacyService services.PrivacyServiceInterface, logger *zap.SugaredLogger, cfg *config.Config) *privacyHandler {
+	return &privacyHandler{
+		BaseHandler:    NewBaseHandler(logger),
+		privacyService: privacyService,
+		logger:         logger,
+		cfg:            cfg,
+	}
+}
+
+// ExportUserData answers the personal data of the user as a JSON attachment
+func (h *privacyHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, err := h.actorAndUser(r)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	export, err := h.privacyService.ExportUserData(r.Context(), actorID, userID)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, userID))
+	w.Header().Set("Cache-Control", "no-store")
+	h.respond(w, export, http.StatusOK)
+}
+
+// EraseUser erases the personal data of the user and answers the erasure receipt
+func (h *privacyHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, err := h.actorAndUser(r)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := h.privacyService.EraseUser(r.Context(), actorID, userID)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, receipt, http.StatusOK)
+}
+
+func (h *privacyHandler) actorAndUser(r *http.Request) (actorID, userID uint, err error) {
+	actor, err := strconv.ParseUint(h.GetAuthenticatedUserID(r.Context()), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	user, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint(actor), uint(user), nil
+}
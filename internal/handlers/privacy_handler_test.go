@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"go.uber.org/zap"
+)
+
+func TestExportUserData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrivacyService := services.NewMockPrivacyServiceInterface(ctrl)
+	handler := NewPrivacyHandler(mockPrivacyService, zap.NewExample().Sugar(), &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/5/export", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "5"})
+	req = req.WithContext(context.WithValue(req.Context(), models.IDContextKey, "5"))
+	w := httptest.NewRecorder()
+
+	export := &models.UserDataExport{
+		Profile:       models.User{ID: 5, Email: "owner@example.com", Role: models.Role{Name: models.StrUser}},
+		VotesCast:     []models.Vote{{UserID: 5, ProfileID: 6, Value: 1}},
+		VotesReceived: []models.Vote{},
+		AuditTrail:    []models.AuditLog{},
+	}
+	mockPrivacyService.EXPECT().ExportUserData(gomock.Any(), uint(5), uint(5)).Return(export, nil)
+
+	handler.ExportUserData(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `attachment; filename="user-5-export.json"`, res.Header.Get("Content-Disposition"))
+
+	var body map[string]json.RawMessage
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	for _, key := range []string{"exported_at", "profile", "votes_cast", "votes_received", "audit_trail"} {
+		assert.Contains(t, body, key)
+	}
+}
+
+func TestEraseUser_AlreadyErased(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrivacyService := services.NewMockPrivacyServiceInterface(ctrl)
+	handler := NewPrivacyHandler(mockPrivacyService, zap.NewExample().Sugar(), &config.Config{})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/5/erase", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "5"})
+	req = req.WithContext(context.WithValue(req.Context(), models.IDContextKey, "1"))
+	w := httptest.NewRecorder()
+
+	mockPrivacyService.EXPECT().EraseUser(gomock.Any(), uint(1), uint(5)).Return(nil, &apperrors.UserErasedErr)
+
+	handler.EraseUser(w, req)
+
+	assert.Equal(t, http.StatusGone, w.Result().StatusCode)
+}
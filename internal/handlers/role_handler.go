@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"go.uber.org/zap"
+)
+
+type roleHandler struct {
+	*BaseHandler
+	roleService services.RoleServiceInterface
+	logger      *zap.SugaredLogger
+	validator   *validator.Validate
+	cfg         *config.Config
+}
+
+func NewRoleHandler(roleService services.RoleServiceInterface, logger *zap.SugaredLogger, validator *validator.Validate, cfg *config.Config) *roleHandler {
+	return &roleHandler{
+		BaseHandler: NewBaseHandler(logger),
+		roleService: roleService,
+		logger:      logger,
+		validator:   validator,
+		cfg:         cfg,
+	}
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Permissions []string `json:"permissions"`
+}
+
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+func (h *roleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleService.ListRoles(r.Context())
+	if err != nil {
+		h.sendError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.respond(w, roles, http.StatusOK)
+}
+
+func (h *roleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	createRoleRequest := &CreateRoleRequest{}
+	err := h.decode(r, createRoleRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.validator.Struct(createRoleRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.roleService.CreateRole(r.Context(), createRoleRequest.Name, createRoleRequest.Permissions)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, role, http.StatusCreated)
+}
+
+func (h *roleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	roleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.roleService.DeleteRole(r.Context(), uint(roleID))
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
+
+func (h *roleHandler) SetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	roleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	rolePermissionsRequest := &RolePermissionsRequest{}
+	err = h.decode(r, rolePermissionsRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.validator.Struct(rolePermissionsRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.roleService.SetRolePermissions(r.Context(), uint(roleID), rolePermissionsRequest.Permissions)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, role, http.StatusOK)
+}
+
+func (h *roleHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.roleService.ListPermissions(r.Context())
+	if err != nil {
+		h.sendError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.respond(w, permissions, http.StatusOK)
+}
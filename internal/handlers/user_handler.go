@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
 	"gitlab.com/jkozhemiaka/web-layout/internal/config"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"gitlab.com/jkozhemiaka/web-layout/internal/passwords"
@@ -18,26 +25,35 @@ import (
 
 type userHandler struct {
 	*BaseHandler
-	userService services.UserServiceInterface
-	logger      *zap.SugaredLogger
-	validator   *validator.Validate
-	cfg         *config.Config
+	userService         services.UserServiceInterface
+	verificationService services.VerificationServiceInterface
+	logger              *zap.SugaredLogger
+	validator           *validator.Validate
+	cfg                 *config.Config
 }
 
-func NewUserHandler(userService services.UserServiceInterface, logger *zap.SugaredLogger, validator *validator.Validate, cfg *config.Config) *userHandler {
+func NewUserHandler(userService services.UserServiceInterface, verificationService services.VerificationServiceInterface, logger *zap.SugaredLogger, validator *validator.Validate, cfg *config.Config) *userHandler {
 	return &userHandler{
-		BaseHandler: NewBaseHandler(logger),
-		userService: userService,
-		logger:      logger,
-		validator:   validator,
-		cfg:         cfg,
+		BaseHandler:         NewBaseHandler(logger),
+		userService:         userService,
+		verificationService: verificationService,
+		logger:              logger,
+		validator:           validator,
+		cfg:                 cfg,
 	}
 }
 
+const (
+	// MergePatchContentType is the media type of JSON Merge Patch documents (RFC 7396)
+	MergePatchContentType = "application/merge-patch+json"
+)
+
 const (
 	defaultPage     = 1
 	defaultPageSize = 10
 	maxPageSize     = 1000
+	// maxSearchLength bounds the q parameter of the user list
+	maxSearchLength = 100
 )
 
 type ErrorResponse struct {
@@ -48,7 +64,23 @@ type CreateUserRequest struct {
 	FirstName string `json:"first_name" validate:"required"`
 	LastName  string `json:"last_name" validate:"required"`
 	Password  string `json:"password" validate:"required,min=8,password"`
-	RoleID    uint   `json:"role_id" validate:"omitempty,oneof=1 2 3"`
+	RoleID    uint   `json:"role_id" validate:"omitempty,gt=0"`
+}
+
+// UpdateUserRequest holds the profile fields, the password has its own endpoint
+type UpdateUserRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	RoleID    uint   `json:"role_id" validate:"omitempty,gt=0"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
 }
 
 func (h *userHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -90,6 +122,13 @@ func (h *userHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The account is created anyway, the user can ask for another link
+	user.ID = userId
+	err = h.verificationService.SendVerificationEmail(r.Context(), user)
+	if err != nil {
+		h.log(r.Context()).Error(err)
+	}
+
 	createUserResponse := &CreateUserResponse{UserId: strconv.Itoa(int(userId))}
 	h.respond(w, createUserResponse, http.StatusCreated)
 }
@@ -103,22 +142,20 @@ func (h *userHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
-	ctx := r.Context()
-	role := h.GetAuthenticatedRole(ctx)
-
-	if role != models.StrAdmin {
-		h.sendError(w, errors.New("premission is denided"), http.StatusBadRequest)
+	version, err := h.expectedVersion(r.Context(), r, userID)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
-	user, err := h.userService.DeleteUser(r.Context(), userID)
+	user, err := h.userService.DeleteUser(r.Context(), userID, version)
 	if err != nil {
-		h.sendError(w, err, http.StatusInternalServerError)
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 	res := &CreateUserResponse{
 		UserID:    user.ID,
-		DeletedAt: user.DeletedAt,
+		DeletedAt: user.DeletedAt.Time,
 	}
 	h.respond(w, res, http.StatusOK)
 }
@@ -127,50 +164,154 @@ func (h *userHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["id"]
 	ctx := r.Context()
-	role := h.GetAuthenticatedRole(ctx)
-
-	if role != models.StrAdmin && userID != vars["id"] {
-		h.sendError(w, errors.New("premission is denided"), http.StatusBadRequest)
-		return
-	}
 
-	createUserRequest := &CreateUserRequest{}
-	err := h.decode(r, createUserRequest)
+	updateUserRequest := &UpdateUserRequest{}
+	err := h.decode(r, updateUserRequest)
 	if err != nil {
 		h.sendError(w, err, http.StatusBadRequest)
 		return
 	}
 	// Validate the User struct
-	err = h.validator.Struct(createUserRequest)
+	err = h.validator.Struct(updateUserRequest)
 	if err != nil {
 		h.sendError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	hash, err := passwords.HashPassword(createUserRequest.Password)
+	updatedData := &models.User{
+		Email:     updateUserRequest.Email,
+		FirstName: updateUserRequest.FirstName,
+		LastName:  updateUserRequest.LastName,
+	}
+
+	if updateUserRequest.RoleID > 0 {
+		if !h.HasPermission(ctx, models.PermUsersUpdateRole) {
+			h.sendError(w, &apperrors.ForbiddenErr, http.StatusForbidden)
+			return
+		}
+		updatedData.RoleID = updateUserRequest.RoleID
+	}
+
+	updatedData.Version, err = h.expectedVersion(ctx, r, userID)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	user, err := h.userService.UpdateUser(ctx, userID, updatedData)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("ETag", UserETag(user))
+	h.respond(w, nil, http.StatusCreated)
+}
+
+// PatchUser applies a JSON Merge Patch to the profile. Fields missing from the
+// document stay unchanged, null clears optional fields.
+func (h *userHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["id"]
+	ctx := r.Context()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+		w.Header().Set("Accept-Patch", MergePatchContentType)
+		h.sendError(w, &apperrors.UnsupportedMediaTypeErr, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	document := map[string]json.RawMessage{}
+	err = h.decode(r, &document)
+	if err != nil {
+		h.sendError(w, apperrors.InvalidPatchErr.AppendMessage(err), http.StatusBadRequest)
+		return
+	}
+
+	patch, err := h.parseUserPatch(document)
 	if err != nil {
 		h.sendError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	updatedData := &models.User{
-		Email:     createUserRequest.Email,
-		FirstName: createUserRequest.FirstName,
-		LastName:  createUserRequest.LastName,
-		Password:  hash,
+	if patch.RoleID != nil && !h.HasPermission(ctx, models.PermUsersUpdateRole) {
+		h.sendError(w, &apperrors.ForbiddenErr, http.StatusForbidden)
+		return
 	}
 
-	if role == models.StrAdmin && createUserRequest.RoleID > 0 {
-		updatedData.RoleID = createUserRequest.RoleID
+	patch.Version, err = h.expectedVersion(ctx, r, userID)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
 	}
 
-	_, err = h.userService.UpdateUser(ctx, userID, updatedData)
+	user, err := h.userService.PatchUser(ctx, userID, patch)
 	if err != nil {
-		h.sendError(w, err, http.StatusNotFound)
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
-	h.respond(w, nil, http.StatusCreated)
+	w.Header().Set("ETag", UserETag(user))
+	h.respond(w, user, http.StatusOK)
+}
+
+// parseUserPatch validates every member of the merge patch document on its own
+func (h *userHandler) parseUserPatch(document map[string]json.RawMessage) (*models.UserPatch, error) {
+	patch := &models.UserPatch{}
+
+	for field, raw := range document {
+		isNull := string(raw) == "null"
+
+		switch field {
+		case "email":
+			if isNull {
+				return nil, apperrors.InvalidPatchErr.AppendMessage("email can't be removed")
+			}
+			email, err := h.patchString(field, raw, "required,email")
+			if err != nil {
+				return nil, err
+			}
+			patch.Email = &email
+		case "first_name", "last_name":
+			value := ""
+			if !isNull {
+				var err error
+				value, err = h.patchString(field, raw, "required")
+				if err != nil {
+					return nil, err
+				}
+			}
+			if field == "first_name" {
+				patch.FirstName = &value
+			} else {
+				patch.LastName = &value
+			}
+		case "role_id":
+			var roleID uint
+			if isNull || json.Unmarshal(raw, &roleID) != nil || roleID == 0 {
+				return nil, apperrors.InvalidPatchErr.AppendMessage("role_id must be a positive number")
+			}
+			patch.RoleID = &roleID
+		case "password":
+			return nil, apperrors.InvalidPatchErr.AppendMessage("the password is changed with PUT /users/{id}/password")
+		default:
+			return nil, apperrors.InvalidPatchErr.AppendMessage("unknown field " + field)
+		}
+	}
+
+	return patch, nil
+}
+
+func (h *userHandler) patchString(field string, raw json.RawMessage, tag string) (string, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", apperrors.InvalidPatchErr.AppendMessage(field + " must be a string")
+	}
+	if err := h.validator.Var(value, tag); err != nil {
+		return "", apperrors.InvalidPatchErr.AppendMessage(field, err)
+	}
+	return value, nil
 }
 
 func (h *userHandler) GetUser(w http.ResponseWriter, r *http.Request) {
@@ -184,9 +325,17 @@ func (h *userHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag := UserETag(user)
+	w.Header().Set("ETag", etag)
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	h.respond(w, user, http.StatusCreated)
 }
 
+// ListUsers pages the users, with the filters and the order of the query string
 func (h *userHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 	ctx := r.Context()
@@ -199,7 +348,14 @@ func (h *userHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		h.sendError(w, err, http.StatusBadRequest)
 		return
 	}
-	users, err := h.userService.ListUsers(ctx, intPage, intPageSize)
+	query, err := h.parseUserListQuery(queryParams)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+	query.Page, query.PageSize = intPage, intPageSize
+
+	users, err := h.userService.ListUsers(ctx, query)
 	if err != nil {
 		h.sendError(w, err, http.StatusBadRequest)
 		return
@@ -208,6 +364,63 @@ func (h *userHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	h.respond(w, users, http.StatusOK)
 }
 
+// parseUserListQuery reads the filters and the sort order of GET /users, the
+// paging is validated by validateListUsersParam
+func (h *userHandler) parseUserListQuery(queryParams url.Values) (*models.UserListQuery, error) {
+	query := &models.UserListQuery{
+		Role:   strings.TrimSpace(queryParams.Get("role")),
+		Search: strings.TrimSpace(queryParams.Get("q")),
+	}
+	if utf8.RuneCountInString(query.Search) > maxSearchLength {
+		return nil, fmt.Errorf("q can't be longer than %d characters", maxSearchLength)
+	}
+
+	var err error
+	if query.CreatedAfter, err = parseTimeParam(queryParams, "created_after"); err != nil {
+		return nil, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(queryParams, "created_before"); err != nil {
+		return nil, err
+	}
+	if minRating := queryParams.Get("min_rating"); minRating != "" {
+		value, err := strconv.Atoi(minRating)
+		if err != nil {
+			return nil, errors.New("min_rating must be an integer")
+		}
+		query.MinRating = &value
+	}
+
+	// sort=rating,-created_at orders by rating, then by creation time descending
+	if sort := queryParams.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			userSort := models.UserSort{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(userSort.Field, "-") {
+				userSort.Field, userSort.Desc = userSort.Field[1:], true
+			}
+			if _, ok := models.UserSortFields[userSort.Field]; !ok {
+				return nil, fmt.Errorf("users can't be sorted by %q", userSort.Field)
+			}
+			query.Sort = append(query.Sort, userSort)
+		}
+	}
+
+	return query, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps and dates (2006-01-02, midnight UTC)
+func parseTimeParam(queryParams url.Values, name string) (*time.Time, error) {
+	value := queryParams.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or a date (YYYY-MM-DD)", name)
+}
+
 func (h *userHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
 	type CreateUserResponse struct {
 		Count uint `json:"count"`
@@ -224,6 +437,106 @@ func (h *userHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
 	h.respond(w, res, http.StatusOK)
 }
 
+// DeletedUserResponse is a soft deleted user as listed to administrators
+type DeletedUserResponse struct {
+	models.User
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (h *userHandler) ListDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	page, pageSize, err := h.validateListUsersParam(queryParams.Get("page"), queryParams.Get("page_size"))
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+	users, err := h.userService.ListDeletedUsers(r.Context(), page, pageSize)
+	if err != nil {
+		h.sendError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]DeletedUserResponse, 0, len(users))
+	for _, user := range users {
+		res = append(res, DeletedUserResponse{User: user, DeletedAt: user.DeletedAt.Time})
+	}
+	h.respond(w, res, http.StatusOK)
+}
+
+// RestoreUser brings back a soft deleted user, unless a live user took the email meanwhile
+func (h *userHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userService.RestoreUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("ETag", UserETag(user))
+	h.respond(w, user, http.StatusOK)
+}
+
+// PurgeUser removes a soft deleted user permanently
+func (h *userHandler) PurgeUser(w http.ResponseWriter, r *http.Request) {
+	err := h.userService.PurgeUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
+
+// VerifyEmail confirms the email address, the token comes from the link query or the JSON body
+func (h *userHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	verifyEmailRequest := &VerifyEmailRequest{Token: r.URL.Query().Get("token")}
+	if r.Method == http.MethodPost {
+		err := h.decode(r, verifyEmailRequest)
+		if err != nil {
+			h.sendError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := h.validator.Struct(verifyEmailRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.verificationService.VerifyEmail(r.Context(), verifyEmailRequest.Token)
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
+
+func (h *userHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	resendRequest := &ResendVerificationRequest{}
+	err := h.decode(r, resendRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.validator.Struct(resendRequest)
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.verificationService.ResendVerificationEmail(r.Context(), resendRequest.Email)
+	if err != nil {
+		h.sendError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Same answer whether the email is registered or not
+	h.respond(w, nil, http.StatusAccepted)
+}
+
 func (h *userHandler) validateListUsersParam(page, pageSize string) (validPage, validPageSize int, err error) {
 	validPage, err = strconv.Atoi(page)
 	if err != nil {
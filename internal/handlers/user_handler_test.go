@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,11 +15,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
 	"gitlab.com/jkozhemiaka/web-layout/internal/config"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"gitlab.com/jkozhemiaka/web-layout/internal/services"
 	myValidate "gitlab.com/jkozhemiaka/web-layout/internal/validate"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func TestCreateUserHandler(t *testing.T) {
@@ -26,6 +29,7 @@ func TestCreateUserHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
 
 	logger := zap.NewExample().Sugar()
 	// Initialize validator
@@ -34,7 +38,7 @@ func TestCreateUserHandler(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	handler := NewUserHandler(mockUserService, logger, validate, cfg)
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
 
 	reqBody := &CreateUserRequest{
 		Email:     "test@example.com",
@@ -52,6 +56,7 @@ func TestCreateUserHandler(t *testing.T) {
 	// Mock the service response
 	mockUserService.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(uint(12345), nil)
 	mockUserService.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockVerificationService.EXPECT().SendVerificationEmail(gomock.Any(), gomock.Any()).Return(nil)
 
 	handler.CreateUserHandler(w, req)
 
@@ -74,6 +79,7 @@ func TestDeleteUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
 
 	logger := zap.NewExample().Sugar()
 	// Initialize validator
@@ -82,7 +88,7 @@ func TestDeleteUser(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	handler := NewUserHandler(mockUserService, logger, validate, cfg)
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
 
 	req := httptest.NewRequest(http.MethodDelete, "/users/123", nil)
 	req = mux.SetURLVars(req, map[string]string{"id": "123"})
@@ -93,8 +99,8 @@ func TestDeleteUser(t *testing.T) {
 	req = req.WithContext(ctx)
 
 	// Mock the service response
-	deletedUser := &models.User{ID: 123, DeletedAt: time.Now()}
-	mockUserService.EXPECT().DeleteUser(gomock.Any(), "123").Return(deletedUser, nil)
+	deletedUser := &models.User{ID: 123, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	mockUserService.EXPECT().DeleteUser(gomock.Any(), "123", uint(0)).Return(deletedUser, nil)
 
 	handler.DeleteUser(w, req)
 
@@ -109,6 +115,7 @@ func TestGetUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
 
 	logger := zap.NewExample().Sugar()
 	// Initialize validator
@@ -117,7 +124,7 @@ func TestGetUser(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	handler := NewUserHandler(mockUserService, logger, validate, cfg)
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
 
 	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
 	req = mux.SetURLVars(req, map[string]string{"id": "123"})
@@ -145,6 +152,7 @@ func TestListUsers(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
 
 	logger := zap.NewExample().Sugar()
 	// Initialize validator
@@ -153,7 +161,7 @@ func TestListUsers(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	handler := NewUserHandler(mockUserService, logger, validate, cfg)
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
 
 	req := httptest.NewRequest(http.MethodGet, "/users", nil)
 	w := httptest.NewRecorder()
@@ -163,7 +171,7 @@ func TestListUsers(t *testing.T) {
 		{ID: 1, Email: "test1@example.com"},
 		{ID: 2, Email: "test2@example.com"},
 	}
-	mockUserService.EXPECT().ListUsers(gomock.Any(), defaultPage, defaultPageSize).Return(users, nil)
+	mockUserService.EXPECT().ListUsers(gomock.Any(), &models.UserListQuery{Page: defaultPage, PageSize: defaultPageSize}).Return(users, nil)
 
 	handler.ListUsers(w, req)
 
@@ -182,6 +190,7 @@ func TestCountUsers(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
 
 	logger := zap.NewExample().Sugar()
 	// Initialize validator
@@ -190,7 +199,7 @@ func TestCountUsers(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	handler := NewUserHandler(mockUserService, logger, validate, cfg)
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
 
 	req := httptest.NewRequest(http.MethodGet, "/users/count", nil)
 	w := httptest.NewRecorder()
@@ -219,6 +228,7 @@ func TestUpdateUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
 
 	logger := zap.NewExample().Sugar()
 	// Initialize validator
@@ -227,7 +237,7 @@ func TestUpdateUser(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	handler := NewUserHandler(mockUserService, logger, validate, cfg)
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
 
 	reqBody := &CreateUserRequest{
 		Email:     "test@example.com",
@@ -245,10 +255,11 @@ func TestUpdateUser(t *testing.T) {
 
 	// Mock the administrator role
 	ctx := context.WithValue(req.Context(), models.RoleContextKey, models.StrAdmin)
+	ctx = context.WithValue(ctx, models.PermissionsContextKey, []string{models.PermUsersUpdateAny, models.PermUsersUpdateRole})
 	req = req.WithContext(ctx)
 
 	// Mock the service response
-	mockUserService.EXPECT().UpdateUser(gomock.Any(), "123", gomock.Any()).Return(nil, nil)
+	mockUserService.EXPECT().UpdateUser(gomock.Any(), "123", gomock.Any()).Return(&models.User{ID: 123, Version: 2}, nil)
 
 	handler.UpdateUser(w, req)
 
@@ -257,3 +268,475 @@ func TestUpdateUser(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, res.StatusCode)
 }
+
+func TestUpdateUser_UpdateFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	reqBody := &CreateUserRequest{
+		Email:     "test@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Password:  "password@123",
+	}
+
+	reqBodyBytes, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPut, "/users/123", bytes.NewReader(reqBodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	ctx := context.WithValue(req.Context(), models.PermissionsContextKey, []string{models.PermUsersUpdateAny})
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	// A database failure must not be reported as a missing user
+	mockUserService.EXPECT().UpdateUser(gomock.Any(), "123", gomock.Any()).Return(nil, apperrors.UpdateFailedErr.AppendMessage("connection reset"))
+
+	handler.UpdateUser(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func TestUpdateUser_EmailInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	reqBody := &UpdateUserRequest{
+		Email:     "taken@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+	}
+
+	reqBodyBytes, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPut, "/users/123", bytes.NewReader(reqBodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	ctx := context.WithValue(req.Context(), models.IDContextKey, "123")
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	mockUserService.EXPECT().UpdateUser(gomock.Any(), "123", gomock.Any()).Return(nil, &apperrors.EmailInUseErr)
+
+	handler.UpdateUser(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestUpdateUser_RoleChangeForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	// Initialize validator
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	cfg := &config.Config{}
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, cfg)
+
+	reqBody := &CreateUserRequest{
+		Email:     "test@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Password:  "password@123",
+		RoleID:    3,
+	}
+
+	reqBodyBytes, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPut, "/users/123", bytes.NewReader(reqBodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	w := httptest.NewRecorder()
+
+	// A regular user updating own profile cannot change the role
+	ctx := context.WithValue(req.Context(), models.RoleContextKey, models.StrUser)
+	ctx = context.WithValue(ctx, models.IDContextKey, "123")
+	req = req.WithContext(ctx)
+
+	handler.UpdateUser(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestPatchUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	// Only the last name changes and the first name is cleared
+	mockUserService.EXPECT().PatchUser(gomock.Any(), "123", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error) {
+			assert.Nil(t, patch.Email)
+			assert.Nil(t, patch.RoleID)
+			assert.Equal(t, "", *patch.FirstName)
+			assert.Equal(t, "Smith", *patch.LastName)
+			return &models.User{ID: 123, LastName: "Smith"}, nil
+		})
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/123", bytes.NewReader([]byte(`{"last_name": "Smith", "first_name": null}`)))
+	req.Header.Set("Content-Type", MergePatchContentType)
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	ctx := context.WithValue(req.Context(), models.IDContextKey, "123")
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	handler.PatchUser(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestPatchUser_InvalidDocument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"invalid email", MergePatchContentType, `{"email": "not-an-email"}`, http.StatusBadRequest},
+		{"email removed", MergePatchContentType, `{"email": null}`, http.StatusBadRequest},
+		{"password", MergePatchContentType, `{"password": "password@123"}`, http.StatusBadRequest},
+		{"unknown field", MergePatchContentType, `{"nickname": "jd"}`, http.StatusBadRequest},
+		{"not an object", MergePatchContentType, `["last_name"]`, http.StatusBadRequest},
+		{"role change without permission", MergePatchContentType, `{"role_id": 1}`, http.StatusForbidden},
+		{"json patch", "application/json-patch+json", `[]`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/users/123", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = mux.SetURLVars(req, map[string]string{"id": "123"})
+			ctx := context.WithValue(req.Context(), models.IDContextKey, "123")
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			handler.PatchUser(w, req)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestPatchUser_Rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+	validate.RegisterValidation("password", myValidate.Password)
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"email in use", `{"email": "taken@example.com"}`, &apperrors.EmailInUseErr, http.StatusConflict},
+		{"unknown role", `{"role_id": 999}`, &apperrors.UnknownRoleErr, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserService.EXPECT().PatchUser(gomock.Any(), "123", gomock.Any()).Return(nil, tt.err)
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/123", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", MergePatchContentType)
+			req = mux.SetURLVars(req, map[string]string{"id": "123"})
+			ctx := context.WithValue(req.Context(), models.PermissionsContextKey, []string{models.PermUsersUpdateAny, models.PermUsersUpdateRole})
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			handler.PatchUser(w, req)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestGetUser_NotModified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	mockUserService.EXPECT().GetUser(gomock.Any(), "123").Return(&models.User{ID: 123, Version: 4}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	req.Header.Set("If-None-Match", `W/"4"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	w := httptest.NewRecorder()
+
+	handler.GetUser(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	assert.Equal(t, `"4"`, res.Header.Get("ETag"))
+}
+
+func TestUpdateUser_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		version uint
+		err     error
+		status  int
+	}{
+		{"matching version", `"4"`, 4, nil, http.StatusCreated},
+		{"stale version", `"3"`, 3, &apperrors.PreconditionFailedErr, http.StatusPreconditionFailed},
+		{"unconditional", "", 0, nil, http.StatusCreated},
+		{"any version", "*", 0, nil, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserService.EXPECT().UpdateUser(gomock.Any(), "123", gomock.Any()).DoAndReturn(
+				func(ctx context.Context, userID string, updatedData *models.User) (*models.User, error) {
+					assert.Equal(t, tt.version, updatedData.Version)
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &models.User{ID: 123, Version: 5}, nil
+				})
+
+			body := []byte(`{"email": "test@example.com", "first_name": "John", "last_name": "Doe"}`)
+			req := httptest.NewRequest(http.MethodPut, "/users/123", bytes.NewReader(body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = mux.SetURLVars(req, map[string]string{"id": "123"})
+			w := httptest.NewRecorder()
+
+			handler.UpdateUser(w, req)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestUpdateUser_WeakIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+
+	logger := zap.NewExample().Sugar()
+	validate := validator.New()
+
+	handler := NewUserHandler(mockUserService, mockVerificationService, logger, validate, &config.Config{})
+
+	// Weak tags never satisfy If-Match, the user must not be touched
+	body := []byte(`{"email": "test@example.com", "first_name": "John", "last_name": "Doe"}`)
+	req := httptest.NewRequest(http.MethodPut, "/users/123", bytes.NewReader(body))
+	req.Header.Set("If-Match", `W/"4"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	w := httptest.NewRecorder()
+
+	handler.UpdateUser(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+}
+
+func TestListDeletedUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+	validate := validator.New()
+	handler := NewUserHandler(mockUserService, mockVerificationService, zap.NewExample().Sugar(), validate, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/deleted?page=2&page_size=5", nil)
+	w := httptest.NewRecorder()
+
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	users := []models.User{{ID: 7, Email: "gone@example.com", DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}}
+	mockUserService.EXPECT().ListDeletedUsers(gomock.Any(), 2, 5).Return(users, nil)
+
+	handler.ListDeletedUsers(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var returnedUsers []DeletedUserResponse
+	_ = json.NewDecoder(res.Body).Decode(&returnedUsers)
+	assert.Len(t, returnedUsers, 1)
+	assert.Equal(t, "gone@example.com", returnedUsers[0].Email)
+	assert.True(t, deletedAt.Equal(returnedUsers[0].DeletedAt))
+}
+
+func TestRestoreUser_EmailTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+	validate := validator.New()
+	handler := NewUserHandler(mockUserService, mockVerificationService, zap.NewExample().Sugar(), validate, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7/restore", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "7"})
+	w := httptest.NewRecorder()
+
+	mockUserService.EXPECT().RestoreUser(gomock.Any(), "7").Return(nil, &apperrors.EmailInUseErr)
+
+	handler.RestoreUser(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
+}
+
+func TestPurgeUser_NotDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+	validate := validator.New()
+	handler := NewUserHandler(mockUserService, mockVerificationService, zap.NewExample().Sugar(), validate, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/7/purge", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "7"})
+	w := httptest.NewRecorder()
+
+	// Live users have to be deleted before they can be purged
+	mockUserService.EXPECT().PurgeUser(gomock.Any(), "7").Return(apperrors.NoRecordFoundErr.AppendMessage("No deleted user found with the given ID."))
+
+	handler.PurgeUser(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestListUsers_FiltersAndSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := services.NewMockUserServiceInterface(ctrl)
+	mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+	handler := NewUserHandler(mockUserService, mockVerificationService, zap.NewExample().Sugar(), validator.New(), &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/users?role=admin&created_after=2024-01-01&created_before=2024-06-30T12:00:00Z&min_rating=-2&q=+Jane+&sort=rating,-created_at&page=2&page_size=20", nil)
+	w := httptest.NewRecorder()
+
+	createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdBefore := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	minRating := -2
+	mockUserService.EXPECT().ListUsers(gomock.Any(), &models.UserListQuery{
+		Page:          2,
+		PageSize:      20,
+		Role:          "admin",
+		CreatedAfter:  &createdAfter,
+		CreatedBefore: &createdBefore,
+		MinRating:     &minRating,
+		Search:        "Jane",
+		Sort:          []models.UserSort{{Field: "rating"}, {Field: "created_at", Desc: true}},
+	}).Return([]models.User{}, nil)
+
+	handler.ListUsers(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestListUsers_InvalidParams(t *testing.T) {
+	tests := map[string]string{
+		"sort field outside the whitelist": "sort=password",
+		"invalid date":                     "created_after=yesterday",
+		"non-integer rating":               "min_rating=high",
+		"search too long":                  "q=" + strings.Repeat("a", maxSearchLength+1),
+	}
+	for name, rawQuery := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The service is never called
+			mockUserService := services.NewMockUserServiceInterface(ctrl)
+			mockVerificationService := services.NewMockVerificationServiceInterface(ctrl)
+			handler := NewUserHandler(mockUserService, mockVerificationService, zap.NewExample().Sugar(), validator.New(), &config.Config{})
+
+			req := httptest.NewRequest(http.MethodGet, "/users?"+rawQuery, nil)
+			w := httptest.NewRecorder()
+
+			handler.ListUsers(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		})
+	}
+}
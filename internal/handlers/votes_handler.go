@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
 	"gitlab.com/jkozhemiaka/web-layout/internal/config"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"gitlab.com/jkozhemiaka/web-layout/internal/services"
@@ -63,6 +64,18 @@ func (h *votesHandler) vote(w http.ResponseWriter, r *http.Request, value int) {
 	}
 
 	ctx := r.Context()
+	if h.cfg.EmailVerificationForVoting {
+		voter, err := h.userService.GetUser(ctx, strconv.Itoa(userID))
+		if err != nil {
+			h.sendError(w, err, http.StatusInternalServerError)
+			return
+		}
+		if voter.EmailVerifiedAt == nil {
+			h.sendError(w, &apperrors.EmailNotVerifiedErr, http.StatusForbidden)
+			return
+		}
+	}
+
 	vote := &models.Vote{
 		UserID:    uint(userID),
 		ProfileID: uint(profileID),
@@ -73,7 +86,7 @@ func (h *votesHandler) vote(w http.ResponseWriter, r *http.Request, value int) {
 	// Attempting to create or update a voice
 	voteId, err := h.userService.Vote(ctx, vote)
 	if err != nil {
-		h.sendError(w, err, http.StatusInternalServerError)
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -118,3 +131,27 @@ func (h *votesHandler) RevokeVote(w http.ResponseWriter, r *http.Request) {
 	createUserResponse := &CreateUserResponse{}
 	h.respond(w, createUserResponse, http.StatusCreated)
 }
+
+// ModerateVote removes a vote cast by any user, used by moderators to clean up abuse
+func (h *votesHandler) ModerateVote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	voterID, err := strconv.Atoi(vars["voter_id"])
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	profileID, err := strconv.Atoi(vars["profile_id"])
+	if err != nil {
+		h.sendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.userService.RevokeVote(r.Context(), uint(voterID), uint(profileID))
+	if err != nil {
+		h.sendError(w, err, h.errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	h.respond(w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ModeDevelopment = "development"
+	ModeProduction  = "production"
+)
+
+// NewLogger returns a colored console logger in development mode and a JSON
+// logger in production mode. LOG_LEVEL overrides the level of the mode.
+func NewLogger(cfg *config.Config) (*zap.Logger, error) {
+	var zapConfig zap.Config
+	switch cfg.LogMode {
+	case ModeDevelopment, "":
+		zapConfig = zap.NewDevelopmentConfig()
+	case ModeProduction:
+		zapConfig = zap.NewProductionConfig()
+		zapConfig.EncoderConfig.TimeKey = "time"
+		zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	default:
+		return nil, fmt.Errorf("unsupported LOG_MODE %q", cfg.LogMode)
+	}
+
+	if cfg.LogLevel != "" {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+			return nil, err
+		}
+		zapConfig.Level = zap.NewAtomicLevelAt(level)
+	}
+
+	return zapConfig.Build()
+}
+
+type contextKey struct{}
+
+// requestLogger is shared by every context derived from the request, so fields
+// added deep in the chain (the user once authenticated) end up in the access log
+type requestLogger struct {
+	mu     sync.Mutex
+	logger *zap.SugaredLogger
+}
+
+// NewContext stores the request-scoped logger in the context
+func NewContext(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestLogger{logger: logger})
+}
+
+// FromContext returns the request-scoped logger, or fallback outside of a request
+func FromContext(ctx context.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	requestLogger, ok := ctx.Value(contextKey{}).(*requestLogger)
+	if !ok {
+		return fallback
+	}
+	requestLogger.mu.Lock()
+	defer requestLogger.mu.Unlock()
+	return requestLogger.logger
+}
+
+// AddFields adds the key-value pairs to every later log line of the request
+func AddFields(ctx context.Context, keysAndValues ...interface{}) {
+	requestLogger, ok := ctx.Value(contextKey{}).(*requestLogger)
+	if !ok {
+		return
+	}
+	requestLogger.mu.Lock()
+	defer requestLogger.mu.Unlock()
+	requestLogger.logger = requestLogger.logger.With(keysAndValues...)
+}
@@ -0,0 +1,98 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+const (
+	TypeFile = "file"
+	TypeSMTP = "smtp"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type MailerInterface interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+func NewMailer(cfg *config.Config) (MailerInterface, error) {
+	switch cfg.MailerType {
+	case TypeFile, "":
+		return NewFileMailer(cfg.MailerFileDir, cfg.MailFrom), nil
+	case TypeSMTP:
+		return NewSMTPMailer(cfg.SMTPAddr, cfg.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unsupported MAILER_TYPE %q", cfg.MailerType)
+	}
+}
+
+// FileMailer writes every message as an .eml file, used for local development
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), sanitizeFileName(msg.To))
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg), 0o644)
+}
+
+// SMTPMailer delivers messages over plain SMTP, e.g. to a MailHog sink in docker-compose
+type SMTPMailer struct {
+	addr string
+	from string
+}
+
+func NewSMTPMailer(addr, from string) *SMTPMailer {
+	return &SMTPMailer{
+		addr: addr,
+		from: from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	return smtp.SendMail(m.addr, nil, m.from, []string{msg.To}, buildMessage(m.from, msg))
+}
+
+func buildMessage(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mailer/mailer.go
+
+// Package mailer is a generated GoMock package.
+package mailer
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMailerInterface is a mock of MailerInterface interface.
+type MockMailerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMailerInterfaceMockRecorder
+}
+
+// MockMailerInterfaceMockRecorder is the mock recorder for MockMailerInterface.
+type MockMailerInterfaceMockRecorder struct {
+	mock *MockMailerInterface
+}
+
+// NewMockMailerInterface creates a new mock instance.
+func NewMockMailerInterface(ctrl *gomock.Controller) *MockMailerInterface {
+	mock := &MockMailerInterface{ctrl: ctrl}
+	mock.recorder = &MockMailerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMailerInterface) EXPECT() *MockMailerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockMailerInterface) Send(ctx context.Context, msg *Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockMailerInterfaceMockRecorder) Send(ctx, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockMailerInterface)(nil).Send), ctx, msg)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "metrics:start_time"
+
+// GormPlugin observes the duration of every query in DBQueryDuration
+type GormPlugin struct {
+	metrics *Metrics
+}
+
+// NewGormPlugin returns the plugin to register with db.Use
+func (m *Metrics) NewGormPlugin() *GormPlugin {
+	return &GormPlugin{metrics: m}
+}
+
+func (p *GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		callback.Create().After("gorm:create").Register("metrics:after_create", p.after("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		callback.Query().After("gorm:query").Register("metrics:after_query", p.after("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		callback.Update().After("gorm:update").Register("metrics:after_update", p.after("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		callback.Row().After("gorm:row").Register("metrics:after_row", p.after("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", p.after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func (p *GormPlugin) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "web_layout"
+
+// Values of the result label
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	CacheHit       = "hit"
+	CacheMiss      = "miss"
+	CacheStale     = "stale"
+	CacheCoalesced = "coalesced"
+	CacheBypass    = "bypass"
+)
+
+// Metrics holds every collector of the service. They are registered on the
+// registry given to New, tests pass a fresh prometheus.NewRegistry().
+type Metrics struct {
+	gatherer prometheus.Gatherer
+
+	HTTPRequests    *prometheus.CounterVec
+	HTTPDuration    *prometheus.HistogramVec
+	DBQueryDuration *prometheus.HistogramVec
+	CacheRequests   *prometheus.CounterVec
+	Logins          *prometheus.CounterVec
+	UsersCreated    prometheus.Counter
+	VotesCast       *prometheus.CounterVec
+}
+
+func New(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		gatherer: registry,
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by operation and table.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		CacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "response_cache_requests_total",
+			Help:      "Cacheable requests by result: hit, miss, stale, coalesced or bypass.",
+		}, []string{"result"}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result.",
+		}, []string{"result"}),
+		UsersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "users_created_total",
+			Help:      "Users created.",
+		}),
+		VotesCast: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "votes_cast_total",
+			Help:      "Votes cast by type.",
+		}, []string{"type"}),
+	}
+
+	registry.MustRegister(
+		m.HTTPRequests,
+		m.HTTPDuration,
+		m.DBQueryDuration,
+		m.CacheRequests,
+		m.Logins,
+		m.UsersCreated,
+		m.VotesCast,
+	)
+	return m
+}
+
+// NewRegistry returns a registry with the Go runtime and process collectors
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler serves the metrics of the registry in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
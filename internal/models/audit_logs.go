@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Audit actions
+const (
+	AuditPasswordChangedByAdmin = "user.password.admin_change"
+	AuditPersonalDataExported   = "user.personal_data.export"
+)
+
+type AuditLog struct {
+	ID           uint      `json:"audit_id" gorm:"primaryKey"`
+	ActorID      uint      `json:"actor_id"`       // User who performed the action
+	TargetUserID uint      `json:"target_user_id"` // User the action was performed on
+	Action       string    `json:"action"`
+	Details      string    `json:"details,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordHistory keeps previous password hashes so they can't be reused
+type PasswordHistory struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	UserID       uint      `json:"-" gorm:"index"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
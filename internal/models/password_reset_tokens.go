@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordResetToken keeps only the SHA-256 hash of the token sent to the user
+type PasswordResetToken struct {
+	ID        uint       `json:"-" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
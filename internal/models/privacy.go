@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// UserDataExport is everything stored about a user, answered to data subject requests
+type UserDataExport struct {
+	ExportedAt    time.Time  `json:"exported_at"`
+	Profile       User       `json:"profile"` // Includes the role and its permissions
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	VotesCast     []Vote     `json:"votes_cast"`
+	VotesReceived []Vote     `json:"votes_received"`
+	AuditTrail    []AuditLog `json:"audit_trail"` // Entries where the user is the actor or the target
+}
+
+// ErasureReceipt records that the personal data of a user was erased. It holds no
+// personal data itself and the database refuses to update or delete it.
+type ErasureReceipt struct {
+	ID                   uint      `json:"receipt_id" gorm:"primaryKey"`
+	UserID               uint      `json:"user_id"`
+	ActorID              uint      `json:"actor_id"` // The user or the administrator who asked for the erasure
+	VotesCastRemoved     int       `json:"votes_cast_removed"`
+	VotesReceivedRemoved int       `json:"votes_received_removed"`
+	ProfilesRecalculated int       `json:"profiles_recalculated"` // Profiles whose rating changed with the removed votes
+	ErasedAt             time.Time `json:"erased_at" gorm:"autoCreateTime"`
+}
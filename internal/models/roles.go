@@ -1,5 +1,7 @@
 package models
 
+import "context"
+
 // Define a custom type for the context key
 type contextKey string
 
@@ -10,12 +12,40 @@ const (
 )
 
 const (
-	RoleContextKey  contextKey = "role"
-	EmailContextKey contextKey = "email"
-	IDContextKey    contextKey = "id"
+	RoleContextKey        contextKey = "role"
+	EmailContextKey       contextKey = "email"
+	IDContextKey          contextKey = "id"
+	PermissionsContextKey contextKey = "permissions"
+)
+
+// Permissions that can be granted to roles
+const (
+	PermUsersDelete     = "users:delete"
+	PermUsersUpdateAny  = "users:update:any"
+	PermUsersUpdateRole = "users:update:role"
+	PermVotesModerate   = "votes:moderate"
+	PermRolesManage     = "roles:manage"
+	PermUsersPrivacy    = "users:privacy" // Export and erase the personal data of any user
 )
 
 type Role struct {
-	ID   uint   `json:"role_id" gorm:"primaryKey"`
+	ID          uint         `json:"role_id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"unique"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions"`
+}
+
+type Permission struct {
+	ID   uint   `json:"permission_id" gorm:"primaryKey"`
 	Name string `json:"name" gorm:"unique"`
 }
+
+// HasPermission reports whether the permissions stored in the context grant the permission
+func HasPermission(ctx context.Context, permission string) bool {
+	permissions, _ := ctx.Value(PermissionsContextKey).([]string)
+	for _, granted := range permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
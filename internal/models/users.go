@@ -2,19 +2,64 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID            uint      `json:"user_id" gorm:"primaryKey"`
-	Email         string    `json:"email"`
-	FirstName     string    `json:"first_name"`
-	LastName      string    `json:"last_name"`
-	Password      string    `json:"-"`
-	Role          Role      `json:"role" gorm:"foreignKey:RoleID"`
-	RoleID        uint      `json:"-"` // RoleID is needed for the foreign key relationship but is not exposed in JSON
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	VoteUpdatedAt time.Time `json:"vote_updated_at"`
-	DeletedAt     time.Time `json:"-" gorm:"index"`
-	Rating        int       `json:"rating"`
+	ID              uint           `json:"user_id" gorm:"primaryKey"`
+	Email           string         `json:"email"`
+	FirstName       string         `json:"first_name"`
+	LastName        string         `json:"last_name"`
+	Password        string         `json:"-"`
+	Role            Role           `json:"role" gorm:"foreignKey:RoleID"`
+	RoleID          uint           `json:"-"` // RoleID is needed for the foreign key relationship but is not exposed in JSON
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	VoteUpdatedAt   time.Time      `json:"vote_updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"` // Set by soft deletion, GORM leaves deleted users out of queries
+	Rating          int            `json:"rating"`
+	TokenVersion    uint           `json:"-" gorm:"not null;default:0"` // Bumped whenever previously issued tokens must stop working
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`           // Nil until the user confirms the email address
+	Version         uint           `json:"-" gorm:"not null;default:1"` // Incremented on every write, used as the ETag
+}
+
+// UserPatch is a partial update of the profile. Nil fields stay unchanged,
+// a pointer to an empty string clears the field.
+type UserPatch struct {
+	Email     *string
+	FirstName *string
+	LastName  *string
+	RoleID    *uint
+	// Version the stored user must have, 0 skips the check
+	Version uint
+}
+
+// UserListQuery filters, orders and pages the user list. Zero values don't filter.
+type UserListQuery struct {
+	Page          int
+	PageSize      int
+	Role          string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	MinRating     *int
+	// Search matches the names and the email, case-insensitively
+	Search string
+	Sort   []UserSort
+}
+
+// UserSort orders the list by one of UserSortFields
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
+// UserSortFields are the fields the user list can be sorted by, mapped to their columns
+var UserSortFields = map[string]string{
+	"id":         "id",
+	"email":      "email",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"created_at": "created_at",
+	"rating":     "rating",
 }
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"gitlab.com/jkozhemiaka/web-layout/internal/tracing"
+	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
 )
 
@@ -16,6 +18,10 @@ type Vote struct {
 
 // AfterSave - a hook to automatically update the rating after saving a vote
 func (v *Vote) AfterSave(tx *gorm.DB) (err error) {
+	ctx, span := otel.Tracer(tracing.InstrumentationName).Start(tx.Statement.Context, "Vote.AfterSave")
+	defer span.End()
+	tx = tx.WithContext(ctx)
+
 	var rating int
 	// Calculate a new rating for the profile that was voted for
 	err = tx.Model(&Vote{}).
@@ -26,14 +32,20 @@ func (v *Vote) AfterSave(tx *gorm.DB) (err error) {
 		return err
 	}
 
-	// Update the rating in the user table
-	err = tx.Model(&User{}).Where("id = ?", v.ProfileID).Update("rating", rating).Error
+	// Update the rating in the user table, the new version changes the ETag of the profile
+	err = tx.Model(&User{}).Where("id = ?", v.ProfileID).Updates(map[string]interface{}{
+		"rating":  rating,
+		"version": gorm.Expr("version + 1"),
+	}).Error
 	if err != nil {
 		return err
 	}
 
 	// Update the UpdatedAt field for the profile
-	err = tx.Model(&User{}).Where("id = ?", v.UserID).Update("vote_updated_at", time.Now()).Error
+	err = tx.Model(&User{}).Where("id = ?", v.UserID).Updates(map[string]interface{}{
+		"vote_updated_at": time.Now(),
+		"version":         gorm.Expr("version + 1"),
+	}).Error
 	if err != nil {
 		return err
 	}
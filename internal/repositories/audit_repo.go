@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AuditRepo struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+type AuditRepoInterface interface {
+	CreateEntry(ctx context.Context, entry *models.AuditLog) error
+}
+
+func NewAuditRepo(db *gorm.DB, logger *zap.SugaredLogger) *AuditRepo {
+	return &AuditRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *AuditRepo) CreateEntry(ctx context.Context, entry *models.AuditLog) error {
+	if err := repo.db.WithContext(ctx).Create(entry).Error; err != nil {
+		logging.FromContext(ctx, repo.logger).Error("Failed to create audit entry", zap.Error(err))
+		return apperrors.InsertionFailedErr.AppendMessage(err)
+	}
+	return nil
+}
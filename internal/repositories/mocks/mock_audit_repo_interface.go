@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repositories/audit_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockAuditRepoInterface is a mock of AuditRepoInterface interface.
+type MockAuditRepoInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepoInterfaceMockRecorder
+}
+
+// MockAuditRepoInterfaceMockRecorder is the mock recorder for MockAuditRepoInterface.
+type MockAuditRepoInterfaceMockRecorder struct {
+	mock *MockAuditRepoInterface
+}
+
+// NewMockAuditRepoInterface creates a new mock instance.
+func NewMockAuditRepoInterface(ctrl *gomock.Controller) *MockAuditRepoInterface {
+	mock := &MockAuditRepoInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditRepoInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepoInterface) EXPECT() *MockAuditRepoInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateEntry mocks base method.
+func (m *MockAuditRepoInterface) CreateEntry(ctx context.Context, entry *models.AuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockAuditRepoInterfaceMockRecorder) CreateEntry(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockAuditRepoInterface)(nil).CreateEntry), ctx, entry)
+}
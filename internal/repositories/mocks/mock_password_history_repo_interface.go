@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repositories/password_history_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPasswordHistoryRepoInterface is a mock of PasswordHistoryRepoInterface interface.
+type MockPasswordHistoryRepoInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordHistoryRepoInterfaceMockRecorder
+}
+
+// MockPasswordHistoryRepoInterfaceMockRecorder is the mock recorder for MockPasswordHistoryRepoInterface.
+type MockPasswordHistoryRepoInterfaceMockRecorder struct {
+	mock *MockPasswordHistoryRepoInterface
+}
+
+// NewMockPasswordHistoryRepoInterface creates a new mock instance.
+func NewMockPasswordHistoryRepoInterface(ctrl *gomock.Controller) *MockPasswordHistoryRepoInterface {
+	mock := &MockPasswordHistoryRepoInterface{ctrl: ctrl}
+	mock.recorder = &MockPasswordHistoryRepoInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordHistoryRepoInterface) EXPECT() *MockPasswordHistoryRepoInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AddHash mocks base method.
+func (m *MockPasswordHistoryRepoInterface) AddHash(ctx context.Context, userID uint, passwordHash string, keep int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddHash", ctx, userID, passwordHash, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddHash indicates an expected call of AddHash.
+func (mr *MockPasswordHistoryRepoInterfaceMockRecorder) AddHash(ctx, userID, passwordHash, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddHash", reflect.TypeOf((*MockPasswordHistoryRepoInterface)(nil).AddHash), ctx, userID, passwordHash, keep)
+}
+
+// ListRecentHashes mocks base method.
+func (m *MockPasswordHistoryRepoInterface) ListRecentHashes(ctx context.Context, userID uint, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecentHashes", ctx, userID, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecentHashes indicates an expected call of ListRecentHashes.
+func (mr *MockPasswordHistoryRepoInterfaceMockRecorder) ListRecentHashes(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecentHashes", reflect.TypeOf((*MockPasswordHistoryRepoInterface)(nil).ListRecentHashes), ctx, userID, limit)
+}
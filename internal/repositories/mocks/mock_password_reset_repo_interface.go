@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repositories/password_reset_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockPasswordResetRepoInterface is a mock of PasswordResetRepoInterface interface.
+type MockPasswordResetRepoInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetRepoInterfaceMockRecorder
+}
+
+// MockPasswordResetRepoInterfaceMockRecorder is the mock recorder for MockPasswordResetRepoInterface.
+type MockPasswordResetRepoInterfaceMockRecorder struct {
+	mock *MockPasswordResetRepoInterface
+}
+
+// NewMockPasswordResetRepoInterface creates a new mock instance.
+func NewMockPasswordResetRepoInterface(ctrl *gomock.Controller) *MockPasswordResetRepoInterface {
+	mock := &MockPasswordResetRepoInterface{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetRepoInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetRepoInterface) EXPECT() *MockPasswordResetRepoInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ConsumeToken mocks base method.
+func (m *MockPasswordResetRepoInterface) ConsumeToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeToken", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeToken indicates an expected call of ConsumeToken.
+func (mr *MockPasswordResetRepoInterfaceMockRecorder) ConsumeToken(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeToken", reflect.TypeOf((*MockPasswordResetRepoInterface)(nil).ConsumeToken), ctx, tokenHash)
+}
+
+// CreateToken mocks base method.
+func (m *MockPasswordResetRepoInterface) CreateToken(ctx context.Context, token *models.PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateToken indicates an expected call of CreateToken.
+func (mr *MockPasswordResetRepoInterfaceMockRecorder) CreateToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockPasswordResetRepoInterface)(nil).CreateToken), ctx, token)
+}
+
+// GetValidToken mocks base method.
+func (m *MockPasswordResetRepoInterface) GetValidToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetValidToken", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetValidToken indicates an expected call of GetValidToken.
+func (mr *MockPasswordResetRepoInterfaceMockRecorder) GetValidToken(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValidToken", reflect.TypeOf((*MockPasswordResetRepoInterface)(nil).GetValidToken), ctx, tokenHash)
+}
+
+// InvalidateUserTokens mocks base method.
+func (m *MockPasswordResetRepoInterface) InvalidateUserTokens(ctx context.Context, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateUserTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateUserTokens indicates an expected call of InvalidateUserTokens.
+func (mr *MockPasswordResetRepoInterfaceMockRecorder) InvalidateUserTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserTokens", reflect.TypeOf((*MockPasswordResetRepoInterface)(nil).InvalidateUserTokens), ctx, userID)
+}
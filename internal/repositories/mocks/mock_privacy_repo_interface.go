@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repositories/privacy_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockPrivacyRepoInterface is a mock of PrivacyRepoInterface interface.
+type MockPrivacyRepoInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyRepoInterfaceMockRecorder
+}
+
+// MockPrivacyRepoInterfaceMockRecorder is the mock recorder for MockPrivacyRepoInterface.
+type MockPrivacyRepoInterfaceMockRecorder struct {
+	mock *MockPrivacyRepoInterface
+}
+
+// NewMockPrivacyRepoInterface creates a new mock instance.
+func NewMockPrivacyRepoInterface(ctrl *gomock.Controller) *MockPrivacyRepoInterface {
+	mock := &MockPrivacyRepoInterface{ctrl: ctrl}
+	mock.recorder = &MockPrivacyRepoInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyRepoInterface) EXPECT() *MockPrivacyRepoInterfaceMockRecorder {
+	return m.recorder
+}
+
+// EraseUser mocks base method.
+func (m *MockPrivacyRepoInterface) EraseUser(ctx context.Context, actorID, userID uint) (*models.ErasureReceipt, []uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, actorID, userID)
+	ret0, _ := ret[0].(*models.ErasureReceipt)
+	ret1, _ := ret[1].([]uint)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockPrivacyRepoInterfaceMockRecorder) EraseUser(ctx, actorID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockPrivacyRepoInterface)(nil).EraseUser), ctx, actorID, userID)
+}
+
+// ExportUser mocks base method.
+func (m *MockPrivacyRepoInterface) ExportUser(ctx context.Context, userID uint) (*models.UserDataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUser", ctx, userID)
+	ret0, _ := ret[0].(*models.UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUser indicates an expected call of ExportUser.
+func (mr *MockPrivacyRepoInterfaceMockRecorder) ExportUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUser", reflect.TypeOf((*MockPrivacyRepoInterface)(nil).ExportUser), ctx, userID)
+}
@@ -0,0 +1,155 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repositories/role_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockRoleRepoInterface is a mock of RoleRepoInterface interface.
+type MockRoleRepoInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepoInterfaceMockRecorder
+}
+
+// MockRoleRepoInterfaceMockRecorder is the mock recorder for MockRoleRepoInterface.
+type MockRoleRepoInterfaceMockRecorder struct {
+	mock *MockRoleRepoInterface
+}
+
+// NewMockRoleRepoInterface creates a new mock instance.
+func NewMockRoleRepoInterface(ctrl *gomock.Controller) *MockRoleRepoInterface {
+	mock := &MockRoleRepoInterface{ctrl: ctrl}
+	mock.recorder = &MockRoleRepoInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepoInterface) EXPECT() *MockRoleRepoInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateRole mocks base method.
+func (m *MockRoleRepoInterface) CreateRole(ctx context.Context, role *models.Role) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, role)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockRoleRepoInterfaceMockRecorder) CreateRole(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockRoleRepoInterface)(nil).CreateRole), ctx, role)
+}
+
+// DeleteRole mocks base method.
+func (m *MockRoleRepoInterface) DeleteRole(ctx context.Context, roleID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockRoleRepoInterfaceMockRecorder) DeleteRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockRoleRepoInterface)(nil).DeleteRole), ctx, roleID)
+}
+
+// GetPermissionsByNames mocks base method.
+func (m *MockRoleRepoInterface) GetPermissionsByNames(ctx context.Context, names []string) ([]models.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPermissionsByNames", ctx, names)
+	ret0, _ := ret[0].([]models.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPermissionsByNames indicates an expected call of GetPermissionsByNames.
+func (mr *MockRoleRepoInterfaceMockRecorder) GetPermissionsByNames(ctx, names interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermissionsByNames", reflect.TypeOf((*MockRoleRepoInterface)(nil).GetPermissionsByNames), ctx, names)
+}
+
+// GetRole mocks base method.
+func (m *MockRoleRepoInterface) GetRole(ctx context.Context, roleID uint) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", ctx, roleID)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockRoleRepoInterfaceMockRecorder) GetRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRoleRepoInterface)(nil).GetRole), ctx, roleID)
+}
+
+// GetRolePermissionNames mocks base method.
+func (m *MockRoleRepoInterface) GetRolePermissionNames(ctx context.Context, roleName string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolePermissionNames", ctx, roleName)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissionNames indicates an expected call of GetRolePermissionNames.
+func (mr *MockRoleRepoInterfaceMockRecorder) GetRolePermissionNames(ctx, roleName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolePermissionNames", reflect.TypeOf((*MockRoleRepoInterface)(nil).GetRolePermissionNames), ctx, roleName)
+}
+
+// ListPermissions mocks base method.
+func (m *MockRoleRepoInterface) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPermissions", ctx)
+	ret0, _ := ret[0].([]models.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPermissions indicates an expected call of ListPermissions.
+func (mr *MockRoleRepoInterfaceMockRecorder) ListPermissions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPermissions", reflect.TypeOf((*MockRoleRepoInterface)(nil).ListPermissions), ctx)
+}
+
+// ListRoles mocks base method.
+func (m *MockRoleRepoInterface) ListRoles(ctx context.Context) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", ctx)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockRoleRepoInterfaceMockRecorder) ListRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockRoleRepoInterface)(nil).ListRoles), ctx)
+}
+
+// SetRolePermissions mocks base method.
+func (m *MockRoleRepoInterface) SetRolePermissions(ctx context.Context, roleID uint, permissions []models.Permission) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRolePermissions", ctx, roleID, permissions)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRolePermissions indicates an expected call of SetRolePermissions.
+func (mr *MockRoleRepoInterfaceMockRecorder) SetRolePermissions(ctx, roleID, permissions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRolePermissions", reflect.TypeOf((*MockRoleRepoInterface)(nil).SetRolePermissions), ctx, roleID, permissions)
+}
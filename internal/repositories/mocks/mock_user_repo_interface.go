@@ -66,18 +66,33 @@ func (mr *MockUserRepoInterfaceMockRecorder) CreateUser(ctx, user interface{}) *
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserRepoInterface) DeleteUser(ctx context.Context, userID string) (*models.User, error) {
+func (m *MockUserRepoInterface) DeleteUser(ctx context.Context, userID string, version uint) (*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID, version)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserRepoInterfaceMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+func (mr *MockUserRepoInterfaceMockRecorder) DeleteUser(ctx, userID, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepoInterface)(nil).DeleteUser), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepoInterface)(nil).DeleteUser), ctx, userID, version)
+}
+
+// GetTokenVersion mocks base method.
+func (m *MockUserRepoInterface) GetTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", ctx, userID)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockUserRepoInterfaceMockRecorder) GetTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockUserRepoInterface)(nil).GetTokenVersion), ctx, userID)
 }
 
 // GetUser mocks base method.
@@ -125,19 +140,93 @@ func (mr *MockUserRepoInterfaceMockRecorder) GetUserByID(ctx, userID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserRepoInterface)(nil).GetUserByID), ctx, userID)
 }
 
+// ListDeletedUsers mocks base method.
+func (m *MockUserRepoInterface) ListDeletedUsers(ctx context.Context, page, pageSize int) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeletedUsers", ctx, page, pageSize)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeletedUsers indicates an expected call of ListDeletedUsers.
+func (mr *MockUserRepoInterfaceMockRecorder) ListDeletedUsers(ctx, page, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeletedUsers", reflect.TypeOf((*MockUserRepoInterface)(nil).ListDeletedUsers), ctx, page, pageSize)
+}
+
 // ListUsers mocks base method.
-func (m *MockUserRepoInterface) ListUsers(ctx context.Context, page, pageSize int) ([]models.User, error) {
+func (m *MockUserRepoInterface) ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUsers", ctx, page, pageSize)
+	ret := m.ctrl.Call(m, "ListUsers", ctx, query)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListUsers indicates an expected call of ListUsers.
-func (mr *MockUserRepoInterfaceMockRecorder) ListUsers(ctx, page, pageSize interface{}) *gomock.Call {
+func (mr *MockUserRepoInterfaceMockRecorder) ListUsers(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserRepoInterface)(nil).ListUsers), ctx, query)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserRepoInterface) MarkEmailVerified(ctx context.Context, userID uint, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserRepoInterfaceMockRecorder) MarkEmailVerified(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserRepoInterface)(nil).MarkEmailVerified), ctx, userID, email)
+}
+
+// PatchUser mocks base method.
+func (m *MockUserRepoInterface) PatchUser(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", ctx, userID, patch)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockUserRepoInterfaceMockRecorder) PatchUser(ctx, userID, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockUserRepoInterface)(nil).PatchUser), ctx, userID, patch)
+}
+
+// PurgeUser mocks base method.
+func (m *MockUserRepoInterface) PurgeUser(ctx context.Context, userID string) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUser", ctx, userID)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUser indicates an expected call of PurgeUser.
+func (mr *MockUserRepoInterfaceMockRecorder) PurgeUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUser", reflect.TypeOf((*MockUserRepoInterface)(nil).PurgeUser), ctx, userID)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserRepoInterface) RestoreUser(ctx context.Context, userID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, userID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserRepoInterfaceMockRecorder) RestoreUser(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserRepoInterface)(nil).ListUsers), ctx, page, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserRepoInterface)(nil).RestoreUser), ctx, userID)
 }
 
 // UpdateUser mocks base method.
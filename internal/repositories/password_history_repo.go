@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepo struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+type PasswordHistoryRepoInterface interface {
+	ListRecentHashes(ctx context.Context, userID uint, limit int) ([]string, error)
+	AddHash(ctx context.Context, userID uint, passwordHash string, keep int) error
+}
+
+func NewPasswordHistoryRepo(db *gorm.DB, logger *zap.SugaredLogger) *PasswordHistoryRepo {
+	return &PasswordHistoryRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *PasswordHistoryRepo) ListRecentHashes(ctx context.Context, userID uint, limit int) ([]string, error) {
+	var hashes []string
+	result := repo.db.WithContext(ctx).
+		Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return hashes, nil
+}
+
+// AddHash stores a previous password hash and drops everything older than the last keep entries
+func (repo *PasswordHistoryRepo) AddHash(ctx context.Context, userID uint, passwordHash string, keep int) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: passwordHash}).Error
+		if err != nil {
+			logging.FromContext(ctx, repo.logger).Error(err)
+			return apperrors.InsertionFailedErr.AppendMessage(err)
+		}
+
+		keepIDs := tx.Model(&models.PasswordHistory{}).
+			Select("id").
+			Where("user_id = ?", userID).
+			Order("created_at DESC, id DESC").
+			Limit(keep)
+		err = tx.Where("user_id = ? AND id NOT IN (?)", userID, keepIDs).Delete(&models.PasswordHistory{}).Error
+		if err != nil {
+			logging.FromContext(ctx, repo.logger).Error(err)
+			return apperrors.DeletionFailedErr.AppendMessage(err.Error())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordResetRepo struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+type PasswordResetRepoInterface interface {
+	CreateToken(ctx context.Context, token *models.PasswordResetToken) error
+	GetValidToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	ConsumeToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	InvalidateUserTokens(ctx context.Context, userID uint) error
+}
+
+func NewPasswordResetRepo(db *gorm.DB, logger *zap.SugaredLogger) *PasswordResetRepo {
+	return &PasswordResetRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *PasswordResetRepo) CreateToken(ctx context.Context, token *models.PasswordResetToken) error {
+	if err := repo.db.WithContext(ctx).Create(token).Error; err != nil {
+		logging.FromContext(ctx, repo.logger).Error("Failed to create password reset token", zap.Error(err))
+		return apperrors.InsertionFailedErr.AppendMessage(err)
+	}
+	return nil
+}
+
+// GetValidToken looks up an unused, unexpired token without spending it
+func (repo *PasswordResetRepo) GetValidToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := repo.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &apperrors.InvalidResetTokenErr
+	}
+	if err != nil {
+		logging.FromContext(ctx, repo.logger).Error(err)
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumeToken marks an unused, unexpired token as used in a single statement so
+// the same token can't be redeemed twice by concurrent requests
+func (repo *PasswordResetRepo) ConsumeToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var tokens []models.PasswordResetToken
+	now := time.Now()
+	result := repo.db.WithContext(ctx).
+		Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, apperrors.UpdateFailedErr.AppendMessage(result.Error.Error())
+	}
+	if result.RowsAffected == 0 || len(tokens) == 0 {
+		return nil, &apperrors.InvalidResetTokenErr
+	}
+	return &tokens[0], nil
+}
+
+func (repo *PasswordResetRepo) InvalidateUserTokens(ctx context.Context, userID uint) error {
+	result := repo.db.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return apperrors.UpdateFailedErr.AppendMessage(result.Error.Error())
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivacyRepo struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+type PrivacyRepoInterface interface {
+	// ExportUser also exports soft deleted users, their data is still stored
+	ExportUser(ctx context.Context, userID uint) (*models.UserDataExport, error)
+	// EraseUser fails with UserErasedErr when the user has already been erased.
+	// It also returns the profiles whose rating changed with the removed votes.
+	EraseUser(ctx context.Context, actorID, userID uint) (*models.ErasureReceipt, []uint, error)
+}
+
+func NewPrivacyRepo(db *gorm.DB, logger *zap.SugaredLogger) *PrivacyRepo {
+	return &PrivacyRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *PrivacyRepo) ExportUser(ctx context.Context, userID uint) (*models.UserDataExport, error) {
+	ctx, span := tracer.Start(ctx, "PrivacyRepo.ExportUser")
+	defer span.End()
+
+	export := &models.UserDataExport{
+		ExportedAt:    time.Now(),
+		VotesCast:     []models.Vote{},
+		VotesReceived: []models.Vote{},
+		AuditTrail:    []models.AuditLog{},
+	}
+	// One snapshot, so the parts of the export agree with each other
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Preload("Role.Permissions").First(&export.Profile, userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NoRecordFoundErr.AppendMessage("User not found.")
+		}
+		if err == nil {
+			err = tx.Order("created_at").Find(&export.VotesCast, "user_id = ?", userID).Error
+		}
+		if err == nil {
+			err = tx.Order("created_at").Find(&export.VotesReceived, "profile_id = ?", userID).Error
+		}
+		if err == nil {
+			err = tx.Order("created_at").Find(&export.AuditTrail, "actor_id = ? OR target_user_id = ?", userID, userID).Error
+		}
+		return err
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		if !apperrors.Is(err, &apperrors.NoRecordFoundErr) {
+			logging.FromContext(ctx, repo.logger).Error(err)
+		}
+		return nil, err
+	}
+
+	if export.Profile.DeletedAt.Valid {
+		export.DeletedAt = &export.Profile.DeletedAt.Time
+	}
+	return export, nil
+}
+
+// EraseUser anonymizes the user and removes the votes cast and received, in one
+// transaction. The ratings of the profiles the user voted for are recalculated
+// and the user stays soft deleted.
+func (repo *PrivacyRepo) EraseUser(ctx context.Context, actorID, userID uint) (*models.ErasureReceipt, []uint, error) {
+	ctx, span := tracer.Start(ctx, "PrivacyRepo.EraseUser")
+	defer span.End()
+
+	receipt := &models.ErasureReceipt{UserID: userID, ActorID: actorID}
+	var recalculatedProfileIDs []uint
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NoRecordFoundErr.AppendMessage("User not found.")
+		}
+		if err != nil {
+			return err
+		}
+		var receipts int64
+		if err := tx.Model(&models.ErasureReceipt{}).Where("user_id = ?", userID).Count(&receipts).Error; err != nil {
+			return err
+		}
+		if receipts > 0 {
+			return &apperrors.UserErasedErr
+		}
+
+		var votedProfileIDs []uint
+		if err := tx.Model(&models.Vote{}).Where("user_id = ?", userID).Pluck("profile_id", &votedProfileIDs).Error; err != nil {
+			return err
+		}
+		result := tx.Where("user_id = ?", userID).Delete(&models.Vote{})
+		if result.Error != nil {
+			return result.Error
+		}
+		receipt.VotesCastRemoved = int(result.RowsAffected)
+		result = tx.Where("profile_id = ?", userID).Delete(&models.Vote{})
+		if result.Error != nil {
+			return result.Error
+		}
+		receipt.VotesReceivedRemoved = int(result.RowsAffected)
+		if len(votedProfileIDs) > 0 {
+			// Only rows whose rating actually changes are updated, so that
+			// RowsAffected counts the recalculated profiles of the receipt
+			ratingExpr := "(SELECT COALESCE(SUM(value), 0) FROM votes WHERE votes.profile_id = users.id)"
+			var recalculated []models.User
+			result = tx.Unscoped().
+				Model(&recalculated).
+				Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+				Where("id IN ?", votedProfileIDs).
+				Where("rating <> " + ratingExpr).
+				Updates(map[string]interface{}{
+					"rating":  gorm.Expr(ratingExpr),
+					"version": gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			receipt.ProfilesRecalculated = int(result.RowsAffected)
+			for _, profile := range recalculated {
+				recalculatedProfileIDs = append(recalculatedProfileIDs, profile.ID)
+			}
+		}
+
+		// The row is kept for the references of the audit log, without anything
+		// that identifies the person. An empty password never matches a hash.
+		deletedAt := user.DeletedAt
+		if !deletedAt.Valid {
+			deletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+		err = tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+			"email":             fmt.Sprintf("erased-%d@erased.invalid", userID),
+			"first_name":        "",
+			"last_name":         "",
+			"password":          "",
+			"email_verified_at": nil,
+			"rating":            0,
+			"deleted_at":        deletedAt,
+			"token_version":     gorm.Expr("token_version + 1"),
+			"version":           gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.PasswordHistory{}, &models.PasswordResetToken{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(receipt).Error
+	})
+	if err != nil {
+		if _, ok := err.(*apperrors.AppError); ok {
+			return nil, nil, err
+		}
+		logging.FromContext(ctx, repo.logger).Error(err)
+		return nil, nil, apperrors.DeletionFailedErr.AppendMessage(err.Error())
+	}
+
+	return receipt, recalculatedProfileIDs, nil
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RoleRepo struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+type RoleRepoInterface interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	GetRole(ctx context.Context, roleID uint) (*models.Role, error)
+	CreateRole(ctx context.Context, role *models.Role) (*models.Role, error)
+	DeleteRole(ctx context.Context, roleID uint) error
+	SetRolePermissions(ctx context.Context, roleID uint, permissions []models.Permission) (*models.Role, error)
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	GetPermissionsByNames(ctx context.Context, names []string) ([]models.Permission, error)
+	GetRolePermissionNames(ctx context.Context, roleName string) ([]string, error)
+}
+
+func NewRoleRepo(db *gorm.DB, logger *zap.SugaredLogger) *RoleRepo {
+	return &RoleRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *RoleRepo) ListRoles(ctx context.Context) ([]models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.ListRoles")
+	defer span.End()
+
+	var roles []models.Role
+	result := repo.db.WithContext(ctx).Preload("Permissions").Order("id").Find(&roles)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return roles, nil
+}
+
+func (repo *RoleRepo) GetRole(ctx context.Context, roleID uint) (*models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.GetRole")
+	defer span.End()
+
+	var role models.Role
+	result := repo.db.WithContext(ctx).Preload("Permissions").First(&role, roleID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NoRecordFoundErr.AppendMessage("Role not found.")
+		}
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return &role, nil
+}
+
+func (repo *RoleRepo) CreateRole(ctx context.Context, role *models.Role) (*models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.CreateRole")
+	defer span.End()
+
+	if err := repo.db.WithContext(ctx).Create(role).Error; err != nil {
+		logging.FromContext(ctx, repo.logger).Error("Failed to create role", zap.Error(err))
+		return nil, apperrors.InsertionFailedErr.AppendMessage(err)
+	}
+	return role, nil
+}
+
+func (repo *RoleRepo) DeleteRole(ctx context.Context, roleID uint) error {
+	ctx, span := tracer.Start(ctx, "RoleRepo.DeleteRole")
+	defer span.End()
+
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Soft deleted users still reference the role and can be restored
+		var usersCount int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("role_id = ?", roleID).Count(&usersCount).Error; err != nil {
+			return err
+		}
+		if usersCount > 0 {
+			return &apperrors.RoleInUseErr
+		}
+
+		role := &models.Role{ID: roleID}
+		if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+			return apperrors.DeletionFailedErr.AppendMessage(err.Error())
+		}
+
+		result := tx.Delete(role)
+		if result.Error != nil {
+			return apperrors.DeletionFailedErr.AppendMessage(result.Error.Error())
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NoRecordFoundErr.AppendMessage("Role not found.")
+		}
+		return nil
+	})
+}
+
+func (repo *RoleRepo) SetRolePermissions(ctx context.Context, roleID uint, permissions []models.Permission) (*models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.SetRolePermissions")
+	defer span.End()
+
+	tx := repo.db.WithContext(ctx)
+
+	role, err := repo.GetRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(role).Association("Permissions").Replace(permissions); err != nil {
+		logging.FromContext(ctx, repo.logger).Error(err)
+		return nil, apperrors.UpdateFailedErr.AppendMessage(err.Error())
+	}
+
+	return role, nil
+}
+
+func (repo *RoleRepo) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.ListPermissions")
+	defer span.End()
+
+	var permissions []models.Permission
+	result := repo.db.WithContext(ctx).Order("name").Find(&permissions)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return permissions, nil
+}
+
+func (repo *RoleRepo) GetPermissionsByNames(ctx context.Context, names []string) ([]models.Permission, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.GetPermissionsByNames")
+	defer span.End()
+
+	var permissions []models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	result := repo.db.WithContext(ctx).Where("name IN ?", names).Find(&permissions)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return permissions, nil
+}
+
+func (repo *RoleRepo) GetRolePermissionNames(ctx context.Context, roleName string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "RoleRepo.GetRolePermissionNames")
+	defer span.End()
+
+	var names []string
+	result := repo.db.WithContext(ctx).
+		Table("permissions").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", roleName).
+		Pluck("permissions.name", &names)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return names, nil
+}
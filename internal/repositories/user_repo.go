@@ -3,16 +3,23 @@ package repositories
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/tracing"
+	"go.opentelemetry.io/otel"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var tracer = otel.Tracer(tracing.InstrumentationName)
+
 type UserRepo struct {
 	db     *gorm.DB
 	logger *zap.SugaredLogger
@@ -21,12 +28,24 @@ type UserRepo struct {
 type UserRepoInterface interface {
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
 	GetUser(ctx context.Context, userID string) (*models.User, error)
-	DeleteUser(ctx context.Context, userID string) (*models.User, error)
+	// DeleteUser, UpdateUser and PatchUser fail with PreconditionFailedErr when a
+	// non-zero version is given and the stored user has another one
+	DeleteUser(ctx context.Context, userID string, version uint) (*models.User, error)
 	UpdateUser(ctx context.Context, userID string, updatedData *models.User) (*models.User, error)
-	ListUsers(ctx context.Context, page int, pageSize int) ([]models.User, error)
+	PatchUser(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error)
+	ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.User, error)
 	CountUsers(ctx context.Context) (int, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
+	GetTokenVersion(ctx context.Context, userID uint) (uint, error)
+	MarkEmailVerified(ctx context.Context, userID uint, email string) error
+	ListDeletedUsers(ctx context.Context, page int, pageSize int) ([]models.User, error)
+	// RestoreUser fails with EmailInUseErr when a live user took the email meanwhile
+	// and with UserErasedErr when the personal data of the user was erased
+	RestoreUser(ctx context.Context, userID string) (*models.User, error)
+	// PurgeUser removes a deleted user for good, with the votes cast and received.
+	// It returns the profiles whose rating was recalculated without those votes.
+	PurgeUser(ctx context.Context, userID string) ([]uint, error)
 }
 
 func NewUserRepo(db *gorm.DB, logger *zap.SugaredLogger) *UserRepo {
@@ -37,10 +56,13 @@ func NewUserRepo(db *gorm.DB, logger *zap.SugaredLogger) *UserRepo {
 }
 
 func (repo *UserRepo) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.CreateUser")
+	defer span.End()
+
 	tx := repo.db.WithContext(ctx)
 	tx.Create(user)
 	if tx.Error != nil {
-		repo.logger.Error(tx.Error)
+		logging.FromContext(ctx, repo.logger).Error(tx.Error)
 		return nil, apperrors.InsertionFailedErr.AppendMessage(tx.Error)
 	}
 
@@ -48,35 +70,45 @@ func (repo *UserRepo) CreateUser(ctx context.Context, user *models.User) (*model
 }
 
 func (repo *UserRepo) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.GetUser")
+	defer span.End()
+
 	tx := repo.db.WithContext(ctx)
 	var user models.User
 
 	// Fetch the user to be updated
-	result := tx.First(&user, "id = ? AND (deleted_at IS NULL OR deleted_at = ?)", userID, time.Time{})
+	result := tx.Preload("Role").First(&user, "id = ?", userID)
 	if result.Error != nil {
 		if result.RowsAffected == 0 {
-			repo.logger.Warn("No user found with the given ID.")
+			logging.FromContext(ctx, repo.logger).Warn("No user found with the given ID.")
 			return nil, apperrors.NoRecordFoundErr.AppendMessage("No user found with the given ID.")
 		}
-		repo.logger.Error(result.Error)
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
 		return nil, apperrors.DeletionFailedErr.AppendMessage(result.Error.Error())
 	}
 
 	return &user, nil
 }
 
-func (repo *UserRepo) DeleteUser(ctx context.Context, userID string) (*models.User, error) {
-	return repo.UpdateUser(ctx, userID, &models.User{DeletedAt: time.Now()})
+func (repo *UserRepo) DeleteUser(ctx context.Context, userID string, version uint) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.DeleteUser")
+	defer span.End()
+
+	return repo.UpdateUser(ctx, userID, &models.User{DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}, Version: version})
 }
 
 func (repo *UserRepo) UpdateUser(ctx context.Context, userID string, updatedData *models.User) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.UpdateUser")
+	defer span.End()
+
 	tx := repo.db.WithContext(ctx)
 
 	// Step 1: Fetch the user to be updated
-	user, err := repo.fetchUser(tx, userID)
+	user, err := repo.fetchUser(tx, userID, updatedData.Version)
 	if err != nil {
 		return nil, err
 	}
+	tokenVersion := user.TokenVersion
 
 	// Step 2: Apply updates to the user
 	err = repo.applyUserUpdates(tx, user, updatedData)
@@ -85,7 +117,7 @@ func (repo *UserRepo) UpdateUser(ctx context.Context, userID string, updatedData
 	}
 
 	// Step 3: Save the updated user to the database
-	err = repo.saveUser(tx, user)
+	err = repo.saveUser(tx, user, tokenVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -94,16 +126,19 @@ func (repo *UserRepo) UpdateUser(ctx context.Context, userID string, updatedData
 }
 
 // Step 1: Fetch the user from the database
-func (repo *UserRepo) fetchUser(tx *gorm.DB, userID string) (*models.User, error) {
+func (repo *UserRepo) fetchUser(tx *gorm.DB, userID string, version uint) (*models.User, error) {
 	var user models.User
-	result := tx.First(&user, "id = ? AND (deleted_at IS NULL OR deleted_at = ?)", userID, time.Time{})
+	result := tx.First(&user, "id = ?", userID)
 	if result.Error != nil {
 		if result.RowsAffected == 0 {
-			repo.logger.Warn("No user found with the given ID.")
+			logging.FromContext(tx.Statement.Context, repo.logger).Warn("No user found with the given ID.")
 			return nil, apperrors.NoRecordFoundErr.AppendMessage("No user found with the given ID.")
 		}
-		repo.logger.Error(result.Error)
-		return nil, apperrors.DeletionFailedErr.AppendMessage(result.Error.Error())
+		logging.FromContext(tx.Statement.Context, repo.logger).Error(result.Error)
+		return nil, apperrors.UpdateFailedErr.AppendMessage(result.Error.Error())
+	}
+	if version != 0 && user.Version != version {
+		return nil, &apperrors.PreconditionFailedErr
 	}
 	return &user, nil
 }
@@ -111,14 +146,11 @@ func (repo *UserRepo) fetchUser(tx *gorm.DB, userID string) (*models.User, error
 // Step 2: Apply updates to the user object
 func (repo *UserRepo) applyUserUpdates(tx *gorm.DB, user *models.User, updatedData *models.User) error {
 	// Check email uniqueness if it changes
-	if updatedData.Email != "" && updatedData.Email != user.Email {
-		var existingUser models.User
-		result := tx.First(&existingUser, "email = ?", updatedData.Email)
-		if result.RowsAffected > 0 {
-			repo.logger.Warn("The email is already occupied by another user.")
-			return apperrors.DeletionFailedErr.AppendMessage("The email is already occupied by another user.")
+	if updatedData.Email != "" {
+		err := repo.changeEmail(tx, user, updatedData.Email)
+		if err != nil {
+			return err
 		}
-		user.Email = updatedData.Email
 	}
 
 	// Update other fields
@@ -128,73 +160,230 @@ func (repo *UserRepo) applyUserUpdates(tx *gorm.DB, user *models.User, updatedDa
 	if updatedData.LastName != "" {
 		user.LastName = updatedData.LastName
 	}
+	// Password, role changes and deletion invalidate every token issued before
 	if updatedData.Password != "" {
 		user.Password = updatedData.Password
+		user.TokenVersion++
 	}
-	if !updatedData.DeletedAt.IsZero() {
+	if updatedData.DeletedAt.Valid {
 		user.DeletedAt = updatedData.DeletedAt
+		user.TokenVersion++
 	}
 	if updatedData.RoleID > 0 {
-		user.RoleID = updatedData.RoleID
+		err := repo.changeRole(tx, user, updatedData.RoleID)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// Step 3: Save the updated user to the database
-func (repo *UserRepo) saveUser(tx *gorm.DB, user *models.User) error {
-	result := tx.Save(&user)
+// changeEmail sets the new address after making sure nobody else uses it
+func (repo *UserRepo) changeEmail(tx *gorm.DB, user *models.User, email string) error {
+	if email == user.Email {
+		return nil
+	}
+
+	var existingUser models.User
+	result := tx.First(&existingUser, "email = ?", email)
+	if result.RowsAffected > 0 {
+		logging.FromContext(tx.Statement.Context, repo.logger).Warn("The email is already occupied by another user.")
+		return &apperrors.EmailInUseErr
+	}
+	user.Email = email
+	// A new address has to be verified again
+	user.EmailVerifiedAt = nil
+	return nil
+}
+
+// changeRole assigns an existing role. A role change invalidates every token issued before.
+func (repo *UserRepo) changeRole(tx *gorm.DB, user *models.User, roleID uint) error {
+	if roleID == user.RoleID {
+		return nil
+	}
+
+	var count int64
+	result := tx.Model(&models.Role{}).Where("id = ?", roleID).Count(&count)
 	if result.Error != nil {
-		repo.logger.Error(result.Error)
-		return apperrors.DeletionFailedErr.AppendMessage(result.Error.Error())
+		logging.FromContext(tx.Statement.Context, repo.logger).Error(result.Error)
+		return apperrors.UpdateFailedErr.AppendMessage(result.Error.Error())
 	}
+	if count == 0 {
+		return &apperrors.UnknownRoleErr
+	}
+	user.RoleID = roleID
+	user.TokenVersion++
 	return nil
 }
 
-func (repo *UserRepo) ListUsers(ctx context.Context, page int, pageSize int) ([]models.User, error) {
-	var users []models.User
+// PatchUser changes only the fields present in the patch. Unlike UpdateUser it can
+// clear optional fields.
+func (repo *UserRepo) PatchUser(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.PatchUser")
+	defer span.End()
+
 	tx := repo.db.WithContext(ctx)
 
+	user, err := repo.fetchUser(tx, userID, patch.Version)
+	if err != nil {
+		return nil, err
+	}
+	tokenVersion := user.TokenVersion
+
+	if patch.Email != nil {
+		err = repo.changeEmail(tx, user, *patch.Email)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if patch.FirstName != nil {
+		user.FirstName = *patch.FirstName
+	}
+	if patch.LastName != nil {
+		user.LastName = *patch.LastName
+	}
+	if patch.RoleID != nil {
+		err = repo.changeRole(tx, user, *patch.RoleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = repo.saveUser(tx, user, tokenVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetUser(ctx, userID)
+}
+
+// Step 3: Save the updated user to the database. The write only succeeds if nobody
+// else saved the user since it was fetched. The rating and the vote time belong to
+// the votes, and the token version is only written when the update revokes tokens.
+func (repo *UserRepo) saveUser(tx *gorm.DB, user *models.User, fetchedTokenVersion uint) error {
+	fetchedVersion := user.Version
+	user.Version++
+
+	omitted := []string{"Role", "CreatedAt", "Rating", "VoteUpdatedAt"}
+	if user.TokenVersion == fetchedTokenVersion {
+		omitted = append(omitted, "TokenVersion")
+	}
+
+	result := tx.Model(user).Where("version = ?", fetchedVersion).Select("*").Omit(omitted...).Updates(user)
+	if result.Error != nil {
+		logging.FromContext(tx.Statement.Context, repo.logger).Error(result.Error)
+		return apperrors.UpdateFailedErr.AppendMessage(result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		logging.FromContext(tx.Statement.Context, repo.logger).Warn("The user has been modified concurrently.")
+		return &apperrors.PreconditionFailedErr
+	}
+	return nil
+}
+
+func (repo *UserRepo) ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.ListUsers")
+	defer span.End()
+
+	var users []models.User
+	tx := repo.db.WithContext(ctx).Scopes(filterUsers(query), sortUsers(query.Sort))
+
 	// Calculate offset for pagination
-	offset := (page - 1) * pageSize
+	offset := (query.Page - 1) * query.PageSize
 
-	result := tx.Limit(pageSize).Offset(offset).Preload("Role").Find(&users, "deleted_at IS NULL OR deleted_at = ?", time.Time{})
+	result := tx.Limit(query.PageSize).Offset(offset).Preload("Role").Find(&users)
 	if result.Error != nil {
-		repo.logger.Error(result.Error)
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
 		return nil, apperrors.DeletionFailedErr.AppendMessage(result.Error.Error())
 	}
 
 	return users, nil
 }
 
+// userSearchExpression must stay the expression of the idx_users_search_trgm
+// index, otherwise searches scan the whole table
+const userSearchExpression = "(users.first_name || ' ' || users.last_name || ' ' || users.email)"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func filterUsers(query *models.UserListQuery) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if query.Role != "" {
+			db = db.Joins("JOIN roles ON roles.id = users.role_id").Where("roles.name = ?", query.Role)
+		}
+		if query.CreatedAfter != nil {
+			db = db.Where("users.created_at > ?", *query.CreatedAfter)
+		}
+		if query.CreatedBefore != nil {
+			db = db.Where("users.created_at < ?", *query.CreatedBefore)
+		}
+		if query.MinRating != nil {
+			db = db.Where("users.rating >= ?", *query.MinRating)
+		}
+		if query.Search != "" {
+			db = db.Where(userSearchExpression+" ILIKE ?", "%"+likeEscaper.Replace(query.Search)+"%")
+		}
+		return db
+	}
+}
+
+// sortUsers orders by whitelisted fields only, the id comes last so pages are stable
+func sortUsers(sorts []models.UserSort) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		byID := false
+		for _, sort := range sorts {
+			column, ok := models.UserSortFields[sort.Field]
+			if !ok {
+				continue
+			}
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: "users", Name: column}, Desc: sort.Desc})
+			byID = byID || column == "id"
+		}
+		if !byID {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: "users", Name: "id"}})
+		}
+		return db
+	}
+}
+
 func (repo *UserRepo) CountUsers(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.CountUsers")
+	defer span.End()
+
 	var count int64
 	tx := repo.db.WithContext(ctx)
-	result := tx.Model(&models.User{}).Where("deleted_at IS NULL OR deleted_at = ?", time.Time{}).Count(&count)
+	result := tx.Model(&models.User{}).Count(&count)
 	if result.Error != nil {
-		repo.logger.Error(result.Error)
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
 		return 0, apperrors.DeletionFailedErr.AppendMessage(result.Error.Error())
 	}
 	return int(count), nil
 }
 
 func (repo *UserRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.GetUserByEmail")
+	defer span.End()
+
 	var user models.User
 	tx := repo.db.WithContext(ctx).
-		Where("email = ? AND (deleted_at IS NULL OR deleted_at = ?)", email, time.Time{}).
+		Where("email = ?", email).
 		Preload("Role").
 		First(&user)
 	if tx.Error != nil {
 		if tx.RowsAffected == 0 {
 			return nil, nil // No user found
 		}
-		repo.logger.Error(tx.Error)
+		logging.FromContext(ctx, repo.logger).Error(tx.Error)
 		return nil, tx.Error
 	}
 	return &user, nil
 }
 
 func (repo *UserRepo) GetUserByID(ctx context.Context, userID uint) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.GetUserByID")
+	defer span.End()
+
 	var user models.User
 	result := repo.db.WithContext(ctx).First(&user, userID)
 	if result.Error != nil {
@@ -205,3 +394,173 @@ func (repo *UserRepo) GetUserByID(ctx context.Context, userID uint) (*models.Use
 	}
 	return &user, nil
 }
+
+func (repo *UserRepo) GetTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.GetTokenVersion")
+	defer span.End()
+
+	var user models.User
+	result := repo.db.WithContext(ctx).
+		Select("token_version").
+		First(&user, "id = ?", userID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return 0, apperrors.NoRecordFoundErr.AppendMessage("User not found.")
+		}
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return 0, result.Error
+	}
+	return user.TokenVersion, nil
+}
+
+// MarkEmailVerified confirms the email only if it is still the user's current address
+func (repo *UserRepo) MarkEmailVerified(ctx context.Context, userID uint, email string) error {
+	ctx, span := tracer.Start(ctx, "UserRepo.MarkEmailVerified")
+	defer span.End()
+
+	result := repo.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Updates(map[string]interface{}{
+			"email_verified_at": time.Now(),
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return apperrors.UpdateFailedErr.AppendMessage(result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NoRecordFoundErr.AppendMessage("User not found.")
+	}
+	return nil
+}
+
+// onlyDeleted selects the soft deleted users, which GORM leaves out otherwise
+func onlyDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("users.deleted_at IS NOT NULL")
+}
+
+// ListDeletedUsers lists the soft deleted users, the most recently deleted first
+func (repo *UserRepo) ListDeletedUsers(ctx context.Context, page int, pageSize int) ([]models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.ListDeletedUsers")
+	defer span.End()
+
+	var users []models.User
+	offset := (page - 1) * pageSize
+	result := repo.db.WithContext(ctx).
+		Scopes(onlyDeleted).
+		Order("users.deleted_at DESC").
+		Limit(pageSize).Offset(offset).
+		Preload("Role").
+		Find(&users)
+	if result.Error != nil {
+		logging.FromContext(ctx, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+
+	return users, nil
+}
+
+func (repo *UserRepo) RestoreUser(ctx context.Context, userID string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.RestoreUser")
+	defer span.End()
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := repo.fetchDeletedUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var receipts int64
+		err = tx.Model(&models.ErasureReceipt{}).Where("user_id = ?", user.ID).Count(&receipts).Error
+		if err != nil {
+			logging.FromContext(ctx, repo.logger).Error(err)
+			return apperrors.UpdateFailedErr.AppendMessage(err.Error())
+		}
+		if receipts > 0 {
+			return &apperrors.UserErasedErr
+		}
+
+		var liveUsers int64
+		err = tx.Model(&models.User{}).Where("email = ?", user.Email).Count(&liveUsers).Error
+		if err != nil {
+			logging.FromContext(ctx, repo.logger).Error(err)
+			return apperrors.UpdateFailedErr.AppendMessage(err.Error())
+		}
+		if liveUsers > 0 {
+			return &apperrors.EmailInUseErr
+		}
+
+		err = tx.Unscoped().Model(user).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			logging.FromContext(ctx, repo.logger).Error(err)
+			return apperrors.UpdateFailedErr.AppendMessage(err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetUser(ctx, userID)
+}
+
+// PurgeUser only removes users that were soft deleted before. The ratings of
+// the profiles the user voted for are recalculated without those votes.
+func (repo *UserRepo) PurgeUser(ctx context.Context, userID string) ([]uint, error) {
+	ctx, span := tracer.Start(ctx, "UserRepo.PurgeUser")
+	defer span.End()
+
+	var votedProfileIDs []uint
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := repo.fetchDeletedUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Vote{}).Where("user_id = ?", user.ID).Pluck("profile_id", &votedProfileIDs).Error
+		if err == nil {
+			err = tx.Where("user_id = ? OR profile_id = ?", user.ID, user.ID).Delete(&models.Vote{}).Error
+		}
+		if err == nil && len(votedProfileIDs) > 0 {
+			err = tx.Unscoped().
+				Model(&models.User{}).
+				Where("id IN ?", votedProfileIDs).
+				Updates(map[string]interface{}{
+					"rating":  gorm.Expr("(SELECT COALESCE(SUM(value), 0) FROM votes WHERE votes.profile_id = users.id)"),
+					"version": gorm.Expr("version + 1"),
+				}).
+				Error
+		}
+		if err == nil {
+			// Reset tokens and the password history go with the user (ON DELETE CASCADE)
+			err = tx.Unscoped().Delete(user).Error
+		}
+		if err != nil {
+			logging.FromContext(ctx, repo.logger).Error(err)
+			return apperrors.DeletionFailedErr.AppendMessage(err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return votedProfileIDs, nil
+}
+
+func (repo *UserRepo) fetchDeletedUser(tx *gorm.DB, userID string) (*models.User, error) {
+	var user models.User
+	result := tx.Scopes(onlyDeleted).First(&user, "id = ?", userID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NoRecordFoundErr.AppendMessage("No deleted user found with the given ID.")
+		}
+		logging.FromContext(tx.Statement.Context, repo.logger).Error(result.Error)
+		return nil, result.Error
+	}
+	return &user, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -30,6 +31,9 @@ func NewVoteRepo(db *gorm.DB, logger *zap.SugaredLogger) *VoteRepo {
 }
 
 func (repo *VoteRepo) GetVote(ctx context.Context, userID uint, profileID uint) (*models.Vote, error) {
+	ctx, span := tracer.Start(ctx, "VoteRepo.GetVote")
+	defer span.End()
+
 	var vote models.Vote
 	result := repo.db.WithContext(ctx).Where("user_id = ? AND profile_id = ?", userID, profileID).First(&vote)
 	if result.Error != nil {
@@ -39,22 +43,31 @@ func (repo *VoteRepo) GetVote(ctx context.Context, userID uint, profileID uint)
 }
 
 func (repo *VoteRepo) CreateVote(ctx context.Context, vote *models.Vote) (*models.Vote, error) {
+	ctx, span := tracer.Start(ctx, "VoteRepo.CreateVote")
+	defer span.End()
+
 	if err := repo.db.WithContext(ctx).Create(vote).Error; err != nil {
-		repo.logger.Error("Failed to create vote", zap.Error(err))
+		logging.FromContext(ctx, repo.logger).Error("Failed to create vote", zap.Error(err))
 		return nil, err
 	}
 	return vote, nil
 }
 
 func (repo *VoteRepo) UpdateVote(ctx context.Context, vote *models.Vote) (*models.Vote, error) {
+	ctx, span := tracer.Start(ctx, "VoteRepo.UpdateVote")
+	defer span.End()
+
 	if err := repo.db.WithContext(ctx).Save(vote).Error; err != nil {
-		repo.logger.Error("Failed to update vote", zap.Error(err))
+		logging.FromContext(ctx, repo.logger).Error("Failed to update vote", zap.Error(err))
 		return nil, err
 	}
 	return vote, nil
 }
 
 func (repo *VoteRepo) DeleteVote(ctx context.Context, userID uint, profileID uint) error {
+	ctx, span := tracer.Start(ctx, "VoteRepo.DeleteVote")
+	defer span.End()
+
 	tx := repo.db.WithContext(ctx)
 
 	// Find the vote
@@ -3,56 +3,89 @@ package server
 import (
 	"bytes"
 	"context"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
-	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/metrics"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type CacheKeyGenerator func(r *http.Request) string
 
-func (srv *server) contextExpire(h http.HandlerFunc, keyGen CacheKeyGenerator, cacheTTL time.Duration) http.HandlerFunc {
+// contextExpire caches successful GET responses under the generated key. The tags
+// let write paths drop whole groups of entries, see invalidateUserCache.
+func (srv *server) contextExpire(h http.HandlerFunc, keyGen CacheKeyGenerator, cacheTTL time.Duration, tags ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 		defer cancel()
+		r = r.WithContext(ctx) // Use the returned request with the new context
 		if r.Method != http.MethodGet {
-			r = r.WithContext(ctx)
+			h(w, r)
+			return
+		}
+
+		// The cache is shared, an entry stored for one caller must never be
+		// served to a caller with other credentials
+		if r.Header.Get("Authorization") != "" {
+			srv.metrics.CacheRequests.WithLabelValues(metrics.CacheBypass).Inc()
+			w.Header().Set("X-Cache", "BYPASS")
 			h(w, r)
 			return
 		}
 
 		// Generate a cacheKey based on a custom function
 		cacheKey := keyGen(r)
-
-		cachedData, err := srv.cache.Get(ctx, cacheKey, cacheTTL)
-		if err == nil {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(cachedData))
+		directives := cacheControlDirectives(r.Header)
+		if directives["no-store"] {
+			srv.metrics.CacheRequests.WithLabelValues(metrics.CacheBypass).Inc()
+			w.Header().Set("X-Cache", "BYPASS")
+			h(w, r)
 			return
 		}
 
-		r = r.WithContext(ctx) // Use the returned request with the new context
+		// no-cache asks for a fresh response, which then replaces the stored one
+		if !directives["no-cache"] {
+			if entry := srv.loadCachedResponse(ctx, cacheKey); entry != nil && entry.matches(r) {
+				age := time.Since(entry.StoredAt)
+				if age < cacheTTL {
+					srv.metrics.CacheRequests.WithLabelValues(metrics.CacheHit).Inc()
+					entry.writeTo(w, r, "HIT")
+					return
+				}
+				if age < cacheTTL+srv.cfg.CacheStaleWhileRevalidate {
+					srv.metrics.CacheRequests.WithLabelValues(metrics.CacheStale).Inc()
+					entry.writeTo(w, r, "STALE")
+					srv.revalidate(r, h, cacheKey, cacheTTL, tags)
+					return
+				}
+			}
+		}
 
-		// Буфер для зберігання відповіді
-		responseBuffer := new(bytes.Buffer)
-		// Створюємо кастомний writer для зберігання відповіді в буфер
-		bufferedWriter := &bufferedResponseWriter{
-			ResponseWriter: w,
-			buffer:         responseBuffer,
+		entry, shared := srv.fetchResponse(r, h, cacheKey, cacheTTL, tags)
+		if shared && !entry.matches(r) {
+			// The response varies on a header this request sends another value of
+			h(w, r)
+			return
 		}
-		h(bufferedWriter, r)
-		if bufferedWriter.statusCode == http.StatusOK || bufferedWriter.statusCode == http.StatusCreated {
-			err := srv.cache.Set(ctx, cacheKey, responseBuffer.String(), cacheTTL)
-			if err != nil {
-				log.Printf("Error caching response: %v", err)
-			}
+		if shared {
+			srv.metrics.CacheRequests.WithLabelValues(metrics.CacheCoalesced).Inc()
+		} else {
+			srv.metrics.CacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
 		}
+		entry.writeTo(w, r, "MISS")
 	}
 }
 
@@ -66,11 +99,8 @@ func (srv *server) jwtMiddleware(h http.HandlerFunc) http.HandlerFunc {
 
 		tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
 
-		claims := &auth.Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(srv.cfg.JwtKey), nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := srv.tokenService.ParseAccessToken(r.Context(), tokenStr)
+		if err != nil {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
@@ -80,32 +110,207 @@ func (srv *server) jwtMiddleware(h http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Reject tokens issued before the user was deleted, demoted or changed the password
+		version, err := srv.userService.GetTokenVersion(r.Context(), claims.ID)
+		if err != nil && !apperrors.Is(err, &apperrors.NoRecordFoundErr) {
+			// An outage must not look like a revoked session, clients would drop their tokens
+			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil || version != claims.TokenVersion {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		permissions, err := srv.roleService.GetRolePermissions(r.Context(), claims.Role)
+		if err != nil {
+			http.Error(w, "Failed to load permissions", http.StatusInternalServerError)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), models.RoleContextKey, claims.Role)
 		ctx = context.WithValue(ctx, models.EmailContextKey, claims.Email)
 		ctx = context.WithValue(ctx, models.IDContextKey, ID)
+		ctx = context.WithValue(ctx, models.PermissionsContextKey, permissions)
+		logging.AddFields(ctx, "user_id", ID, "role", claims.Role)
 		r = r.WithContext(ctx)
 		h(w, r)
 	}
 }
 
-// bufferedResponseWriter використовується для зберігання тіла відповіді
-type bufferedResponseWriter struct {
+// requirePermission lets the request through only if the role of the authenticated
+// user grants the permission. It must be wrapped by jwtMiddleware.
+func (srv *server) requirePermission(permission string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !models.HasPermission(r.Context(), permission) {
+			http.Error(w, "Permission is denied", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// requirePermissionOrSelf is like requirePermission but also lets users act on
+// their own resource identified by the {id} route variable
+func (srv *server) requirePermissionOrSelf(permission string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ID, _ := r.Context().Value(models.IDContextKey).(string)
+		if ID != mux.Vars(r)["id"] && !models.HasPermission(r.Context(), permission) {
+			http.Error(w, "Permission is denied", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// invalidateUserCache drops the cached responses made stale by a successful write:
+// the user identified by the idVar route variable, if any, and every cached user
+// list and count
+func (srv *server) invalidateUserCache(idVar string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		h(recorder, r)
+		if recorder.statusCode >= http.StatusMultipleChoices {
+			return
+		}
+
+		if userID := mux.Vars(r)[idVar]; userID != "" {
+			err := srv.cache.Delete(r.Context(), constants.UserCacheKeyPrefix+userID)
+			if err != nil {
+				logging.FromContext(r.Context(), srv.logger).Errorf("Error invalidating cached user %s: %v", userID, err)
+			}
+		}
+		err := srv.cache.InvalidateTags(r.Context(), constants.UsersListCacheTag, constants.UsersCountCacheTag)
+		if err != nil {
+			logging.FromContext(r.Context(), srv.logger).Errorf("Error invalidating cached user lists: %v", err)
+		}
+	}
+}
+
+// trace continues the trace of the W3C traceparent header or starts a new one,
+// the span is named after the route template
+func (srv *server) trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(tracing.InstrumentationName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.statusCode))
+		if recorder.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.statusCode))
+		}
+	})
+}
+
+// instrument counts the request and observes its latency under the route
+// template, so /users/1 and /users/2 share their series
+func (srv *server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		srv.metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.statusCode)).Inc()
+		srv.metrics.HTTPDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeTemplate returns the template of the matched mux route, /users/{id:[0-9]+}
+// rather than /users/1
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unknown"
+}
+
+// countSuccess increments the counter when the handler answers with a 2xx status
+func (srv *server) countSuccess(counter prometheus.Counter, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		h(recorder, r)
+		if recorder.statusCode < http.StatusMultipleChoices {
+			counter.Inc()
+		}
+	}
+}
+
+// countOutcome increments the success or the failure series of the counter
+// depending on the status of the response
+func (srv *server) countOutcome(counter *prometheus.CounterVec, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		h(recorder, r)
+		result := metrics.ResultSuccess
+		if recorder.statusCode >= http.StatusMultipleChoices {
+			result = metrics.ResultFailure
+		}
+		counter.WithLabelValues(result).Inc()
+	}
+}
+
+// statusRecorder remembers the status code written by the handler and counts
+// the bytes of the body
+type statusRecorder struct {
 	http.ResponseWriter
+	statusCode int
+	bytes      int
+}
+
+func (sr *statusRecorder) WriteHeader(statusCode int) {
+	sr.statusCode = statusCode
+	sr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (sr *statusRecorder) Write(data []byte) (int, error) {
+	n, err := sr.ResponseWriter.Write(data)
+	sr.bytes += n
+	return n, err
+}
+
+// bufferedResponseWriter keeps the whole response in memory so it can be stored
+// and handed to every request waiting for it
+type bufferedResponseWriter struct {
+	header     http.Header
 	buffer     *bytes.Buffer
 	statusCode int
 }
 
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{
+		header:     http.Header{},
+		buffer:     new(bytes.Buffer),
+		statusCode: http.StatusOK,
+	}
+}
+
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
 func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
-	bw.buffer.Write(b)
-	return bw.ResponseWriter.Write(b)
+	return bw.buffer.Write(b)
 }
 
 func (bw *bufferedResponseWriter) WriteHeader(statusCode int) {
 	bw.statusCode = statusCode
-	bw.ResponseWriter.WriteHeader(statusCode)
 }
 
 func CacheGenId(r *http.Request) string {
 	vars := mux.Vars(r)
-	return "user:" + vars["id"]
+	return constants.UserCacheKeyPrefix + vars["id"]
 }
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/metrics"
+	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"gitlab.com/jkozhemiaka/web-layout/internal/tracing"
+)
+
+func newTestServer(t *testing.T, responseCache cache.CacheInterface, cfg *config.Config) *server {
+	return &server{
+		cache:   responseCache,
+		logger:  zaptest.NewLogger(t).Sugar(),
+		cfg:     cfg,
+		metrics: metrics.New(prometheus.NewRegistry()),
+	}
+}
+
+func TestContextExpire_TagsCachedResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	mockCache.EXPECT().Get(gomock.Any(), "users_list_page_1_size_10", gomock.Any()).Return("", cache.ErrCacheMiss)
+	mockCache.EXPECT().Set(gomock.Any(), "users_list_page_1_size_10", gomock.Any(), time.Minute).Return(nil)
+	mockCache.EXPECT().Tag(gomock.Any(), "users_list_page_1_size_10", time.Minute, "users_list").Return(nil)
+
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[]`))
+	}, generateUsersListCacheKey, time.Minute, "users_list")
+
+	req := httptest.NewRequest(http.MethodGet, "/users?page=1&page_size=10", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestInvalidateUserCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	mockCache.EXPECT().Delete(gomock.Any(), "user:123").Return(nil)
+	mockCache.EXPECT().InvalidateTags(gomock.Any(), "users_list", "users_count").Return(nil)
+
+	handler := srv.invalidateUserCache("id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/users/123", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+}
+
+func TestInvalidateUserCache_FailedWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Nothing changed, so nothing is evicted
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	handler := srv.invalidateUserCache("id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/users/123", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "123"})
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+}
+
+func TestInvalidateUserCache_NewUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// A new user only changes the lists and the count
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	mockCache.EXPECT().InvalidateTags(gomock.Any(), "users_list", "users_count").Return(nil)
+
+	handler := srv.invalidateUserCache("", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+}
+
+func TestInstrument_LabelsByRouteTemplate(t *testing.T) {
+	srv := newTestServer(t, nil, &config.Config{})
+	srv.router = &router{mux: mux.NewRouter()}
+	srv.router.Use(srv.instrument)
+	srv.router.Get("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	requests := srv.metrics.HTTPRequests.WithLabelValues("/users/{id:[0-9]+}", http.MethodGet, "404")
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests))
+}
+
+func TestCountOutcome(t *testing.T) {
+	srv := newTestServer(t, nil, &config.Config{})
+	handler := srv.countOutcome(srv.metrics.Logins, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(srv.metrics.Logins.WithLabelValues(metrics.ResultFailure)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(srv.metrics.Logins.WithLabelValues(metrics.ResultSuccess)))
+}
+
+func TestTrace_ContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	srv := newTestServer(t, nil, &config.Config{})
+	srv.router = &router{mux: mux.NewRouter()}
+	srv.router.Use(srv.trace)
+	srv.router.Get("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "GET /users/{id:[0-9]+}", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestJwtMiddleware_TokenVersionLookup(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    uint
+		err        error
+		wantStatus int
+	}{
+		{name: "current version", version: 3, wantStatus: http.StatusOK},
+		{name: "bumped version", version: 4, wantStatus: http.StatusUnauthorized},
+		{name: "deleted user", err: apperrors.NoRecordFoundErr.AppendMessage("User not found."), wantStatus: http.StatusUnauthorized},
+		{name: "database down", err: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+			mockUserService := services.NewMockUserServiceInterface(ctrl)
+			mockRoleService := services.NewMockRoleServiceInterface(ctrl)
+			srv := newTestServer(t, nil, &config.Config{})
+			srv.tokenService = mockTokenService
+			srv.userService = mockUserService
+			srv.roleService = mockRoleService
+
+			mockTokenService.EXPECT().ParseAccessToken(gomock.Any(), "token").
+				Return(&auth.Claims{ID: 7, Email: "test@example.com", Role: "user", TokenVersion: 3}, nil)
+			mockUserService.EXPECT().GetTokenVersion(gomock.Any(), uint(7)).Return(test.version, test.err)
+			mockRoleService.EXPECT().GetRolePermissions(gomock.Any(), "user").Return(nil, nil).AnyTimes()
+
+			handler := srv.jwtMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, test.wantStatus, w.Result().StatusCode)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds the IDs accepted from clients, longer or oddly
+	// formatted ones are replaced so they can't flood or forge log lines
+	maxRequestIDLength = 128
+)
+
+// logRequests gives every request an ID, kept from the X-Request-ID header when
+// the client sent a sane one, and a logger tagged with it. Once the request is
+// served it writes the access log line. It wraps the router, so unmatched
+// requests are logged too.
+func (srv *server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := logging.NewContext(r.Context(), srv.logger.With("request_id", requestID))
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		keysAndValues := []interface{}{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.statusCode,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		}
+		logger := logging.FromContext(ctx, srv.logger)
+		if recorder.statusCode >= http.StatusInternalServerError {
+			logger.Errorw("request", keysAndValues...)
+			return
+		}
+		logger.Infow("request", keysAndValues...)
+	})
+}
+
+// logRoute adds the matched route template and the trace ID to the request logger
+func (srv *server) logRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keysAndValues := []interface{}{"route", routeTemplate(r)}
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			keysAndValues = append(keysAndValues, "trace_id", spanContext.TraceID().String())
+		}
+		logging.AddFields(r.Context(), keysAndValues...)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		isAlphanumeric := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if !isAlphanumeric && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+)
+
+func TestLogRequests_AccessLog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	srv := newTestServer(t, nil, &config.Config{})
+	srv.logger = zap.New(core).Sugar()
+	srv.router = &router{mux: mux.NewRouter()}
+	srv.router.Use(srv.logRoute)
+	srv.router.Get("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		logging.AddFields(r.Context(), "user_id", "7", "role", "admin")
+		logging.FromContext(r.Context(), nil).Info("loading user")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "abc-123", entries[0].ContextMap()["request_id"])
+	assert.Equal(t, "/users/{id:[0-9]+}", entries[0].ContextMap()["route"])
+
+	accessLog := entries[1].ContextMap()
+	assert.Equal(t, "request", entries[1].Message)
+	assert.Equal(t, "abc-123", accessLog["request_id"])
+	assert.Equal(t, "7", accessLog["user_id"])
+	assert.Equal(t, "admin", accessLog["role"])
+	assert.Equal(t, int64(http.StatusNotFound), accessLog["status"])
+	assert.Equal(t, int64(len("not found")), accessLog["bytes"])
+}
+
+func TestLogRequests_ReplacesInvalidRequestID(t *testing.T) {
+	srv := newTestServer(t, nil, &config.Config{})
+	srv.router = &router{mux: mux.NewRouter()}
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.Header.Set(RequestIDHeader, "forged\nlog line")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	requestID := w.Header().Get(RequestIDHeader)
+	assert.Len(t, requestID, 32)
+	assert.True(t, validRequestID(requestID))
+}
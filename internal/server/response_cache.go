@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/handlers"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+)
+
+// cachedResponse is what contextExpire keeps in the cache for a GET request
+type cachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+	// Values of the request headers listed in the Vary response header
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// Request headers that make the handler answer differently than for a plain GET.
+// They are removed when a response is fetched for the cache and evaluated per
+// request when the entry is written.
+var conditionalHeaders = []string{"If-None-Match", "If-Match", "If-Modified-Since", "If-Unmodified-Since"}
+
+// Headers that describe a single exchange and must not be replayed from the cache
+var uncachedHeaders = []string{"Age", "X-Cache", "Set-Cookie"}
+
+func newCachedResponse(r *http.Request, status int, header http.Header, body []byte) *cachedResponse {
+	entry := &cachedResponse{
+		Status:   status,
+		Header:   header.Clone(),
+		Body:     body,
+		StoredAt: time.Now(),
+	}
+	for _, name := range uncachedHeaders {
+		entry.Header.Del(name)
+	}
+
+	for _, name := range headerTokens(header, "Vary") {
+		if entry.Vary == nil {
+			entry.Vary = map[string]string{}
+		}
+		entry.Vary[http.CanonicalHeaderKey(name)] = r.Header.Get(name)
+	}
+	return entry
+}
+
+// matches reports whether the request sends the same values as the one the
+// response was stored for in every header the response varies on
+func (entry *cachedResponse) matches(r *http.Request) bool {
+	for name, value := range entry.Vary {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (entry *cachedResponse) writeTo(w http.ResponseWriter, r *http.Request, cacheStatus string) {
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	if cacheStatus != "MISS" {
+		w.Header().Set("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+
+	if etag := entry.Header.Get("ETag"); handlers.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+// renderTimeout bounds a shared render, which no longer follows the cancellation
+// of the request that started it
+const renderTimeout = time.Minute
+
+// fetchResponse runs the handler and stores the response. Concurrent calls for
+// the same key wait for the first one and share its response, shared reports
+// whether this call was one of the waiting ones. The render doesn't stop when
+// the first client goes away, the waiting ones would get its aborted response.
+func (srv *server) fetchResponse(r *http.Request, h http.HandlerFunc, cacheKey string, cacheTTL time.Duration, tags []string) (entry *cachedResponse, shared bool) {
+	result, _, shared := srv.responses.Do(cacheKey, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), renderTimeout)
+		defer cancel()
+		return srv.renderResponse(r.WithContext(ctx), h, cacheKey, cacheTTL, tags), nil
+	})
+	return result.(*cachedResponse), shared
+}
+
+// revalidate refreshes the entry in the background. The refresh outlives the
+// request that triggered it and at most one runs per key.
+func (srv *server) revalidate(r *http.Request, h http.HandlerFunc, cacheKey string, cacheTTL time.Duration, tags []string) {
+	srv.responses.DoChan(cacheKey, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), renderTimeout)
+		defer cancel()
+		return srv.renderResponse(r.WithContext(ctx), h, cacheKey, cacheTTL, tags), nil
+	})
+}
+
+func (srv *server) renderResponse(r *http.Request, h http.HandlerFunc, cacheKey string, cacheTTL time.Duration, tags []string) *cachedResponse {
+	r = r.Clone(r.Context())
+	for _, name := range conditionalHeaders {
+		r.Header.Del(name)
+	}
+
+	bufferedWriter := newBufferedResponseWriter()
+	h(bufferedWriter, r)
+
+	entry := newCachedResponse(r, bufferedWriter.statusCode, bufferedWriter.Header(), bufferedWriter.buffer.Bytes())
+	if isStorable(entry.Status, entry.Header) {
+		srv.storeCachedResponse(r.Context(), cacheKey, cacheTTL+srv.cfg.CacheStaleWhileRevalidate, entry, tags)
+	}
+	return entry
+}
+
+// loadCachedResponse returns nil on a miss. Errors are logged and treated as a miss
+// so that the cache never breaks a request.
+func (srv *server) loadCachedResponse(ctx context.Context, cacheKey string) *cachedResponse {
+	cachedData, err := srv.cache.Get(ctx, cacheKey, 0)
+	if err != nil {
+		return nil
+	}
+
+	var entry cachedResponse
+	if err := json.Unmarshal([]byte(cachedData), &entry); err != nil || entry.Status == 0 {
+		return nil
+	}
+	return &entry
+}
+
+func (srv *server) storeCachedResponse(ctx context.Context, cacheKey string, cacheTTL time.Duration, entry *cachedResponse, tags []string) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logging.FromContext(ctx, srv.logger).Errorf("Error encoding response: %v", err)
+		return
+	}
+
+	err = srv.cache.Set(ctx, cacheKey, string(data), cacheTTL)
+	if err != nil {
+		logging.FromContext(ctx, srv.logger).Errorf("Error caching response: %v", err)
+		return
+	}
+	if len(tags) > 0 {
+		err = srv.cache.Tag(ctx, cacheKey, cacheTTL, tags...)
+		if err != nil {
+			logging.FromContext(ctx, srv.logger).Errorf("Error tagging cached response: %v", err)
+		}
+	}
+}
+
+// isStorable reports whether a shared cache may keep the response
+func isStorable(status int, header http.Header) bool {
+	if status != http.StatusOK && status != http.StatusCreated {
+		return false
+	}
+
+	directives := cacheControlDirectives(header)
+	if directives["no-store"] || directives["private"] {
+		return false
+	}
+	for _, name := range headerTokens(header, "Vary") {
+		if name == "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheControlDirectives returns the Cache-Control directives without their
+// arguments. The legacy "Pragma: no-cache" counts as no-cache.
+func cacheControlDirectives(header http.Header) map[string]bool {
+	directives := map[string]bool{}
+	for _, directive := range headerTokens(header, "Cache-Control") {
+		name, _, _ := strings.Cut(directive, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	for _, pragma := range headerTokens(header, "Pragma") {
+		if strings.EqualFold(pragma, "no-cache") {
+			directives["no-cache"] = true
+		}
+	}
+	return directives
+}
+
+// headerTokens splits the comma separated values of a header
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+func userRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	return mux.SetURLVars(req, map[string]string{"id": "123"})
+}
+
+func cachedEntry(t *testing.T, entry *cachedResponse) string {
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestContextExpire_Hit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	entry := &cachedResponse{
+		Status:   http.StatusCreated,
+		Header:   http.Header{"Content-Type": {"application/json"}, "Etag": {`"3"`}},
+		Body:     []byte(`{"user_id":123}`),
+		StoredAt: time.Now().Add(-10 * time.Second),
+	}
+	mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return(cachedEntry(t, entry), nil).Times(2)
+
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("the handler must not run on a hit")
+	}, generateUserCacheKey, time.Minute)
+
+	// The stored status, headers and body are replayed
+	w := httptest.NewRecorder()
+	handler(w, userRequest())
+	res := w.Result()
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.Equal(t, "HIT", res.Header.Get("X-Cache"))
+	assert.Equal(t, "10", res.Header.Get("Age"))
+	assert.Equal(t, `{"user_id":123}`, w.Body.String())
+
+	req := userRequest()
+	req.Header.Set("If-None-Match", `"3"`)
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Result().StatusCode)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestContextExpire_Miss(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return("", cache.ErrCacheMiss)
+	mockCache.EXPECT().Set(gomock.Any(), "user:123", gomock.Any(), time.Minute).DoAndReturn(
+		func(ctx interface{}, key, value string, cacheTTL time.Duration) error {
+			var entry cachedResponse
+			assert.NoError(t, json.Unmarshal([]byte(value), &entry))
+			assert.Equal(t, http.StatusCreated, entry.Status)
+			assert.Equal(t, "application/json", entry.Header.Get("Content-Type"))
+			assert.Empty(t, entry.Header.Get("X-Cache"))
+			assert.Equal(t, `{"user_id":123}`, string(entry.Body))
+			return nil
+		})
+
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"user_id":123}`))
+	}, generateUserCacheKey, time.Minute)
+
+	w := httptest.NewRecorder()
+	handler(w, userRequest())
+	assert.Equal(t, "MISS", w.Result().Header.Get("X-Cache"))
+}
+
+func TestContextExpire_NotShared(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No cache calls are expected at all
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	tests := []struct {
+		name          string
+		requestHeader http.Header
+		responseCache string
+	}{
+		{"authorized request", http.Header{"Authorization": {"Bearer token"}}, ""},
+		{"request no-store", http.Header{"Cache-Control": {"no-store"}}, ""},
+		{"private response", http.Header{}, "private, max-age=60"},
+		{"no-store response", http.Header{}, "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.requestHeader.Get("Authorization") == "" && tt.requestHeader.Get("Cache-Control") == "" {
+				mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return("", cache.ErrCacheMiss)
+			}
+
+			handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+				if tt.responseCache != "" {
+					w.Header().Set("Cache-Control", tt.responseCache)
+				}
+				w.WriteHeader(http.StatusOK)
+			}, generateUserCacheKey, time.Minute)
+
+			req := userRequest()
+			req.Header = tt.requestHeader
+			w := httptest.NewRecorder()
+			handler(w, req)
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestContextExpire_NoCacheRefreshesEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The stored entry is not read but replaced
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+	mockCache.EXPECT().Set(gomock.Any(), "user:123", gomock.Any(), time.Minute).Return(nil)
+
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, generateUserCacheKey, time.Minute)
+
+	req := userRequest()
+	req.Header.Set("Cache-Control", "no-cache")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, "MISS", w.Result().Header.Get("X-Cache"))
+}
+
+func TestContextExpire_Vary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	// The entry was stored for another Accept-Language, so the handler runs again
+	entry := &cachedResponse{
+		Status:   http.StatusOK,
+		Header:   http.Header{"Vary": {"Accept-Language"}},
+		StoredAt: time.Now(),
+		Vary:     map[string]string{"Accept-Language": "uk"},
+	}
+	mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return(cachedEntry(t, entry), nil)
+	mockCache.EXPECT().Set(gomock.Any(), "user:123", gomock.Any(), time.Minute).Return(nil)
+
+	called := false
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Vary", "Accept-Language")
+		w.WriteHeader(http.StatusOK)
+	}, generateUserCacheKey, time.Minute)
+
+	req := userRequest()
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	assert.True(t, called)
+}
+
+func TestContextExpire_CoalescesConcurrentMisses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return("", cache.ErrCacheMiss).AnyTimes()
+	mockCache.EXPECT().Set(gomock.Any(), "user:123", gomock.Any(), time.Minute).Return(nil).Times(1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"user_id":123}`))
+	}, generateUserCacheKey, time.Minute)
+
+	const requests = 5
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, requests)
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			handler(w, userRequest())
+		}(recorders[i])
+		if i == 0 {
+			<-started
+		}
+	}
+	// Give the other requests time to join the running one
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, w := range recorders {
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"user_id":123}`, w.Body.String())
+	}
+}
+
+func TestContextExpire_RenderOutlivesCanceledRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	srv := newTestServer(t, mockCache, &config.Config{})
+
+	mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return("", cache.ErrCacheMiss).AnyTimes()
+	mockCache.EXPECT().Set(gomock.Any(), "user:123", gomock.Any(), time.Minute).Return(nil).Times(1)
+
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Err() != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"user_id":123}`))
+	}, generateUserCacheKey, time.Minute)
+
+	// The client that starts the render is gone, the requests sharing it must not see that
+	req := userRequest()
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	w := httptest.NewRecorder()
+	handler(w, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"user_id":123}`, w.Body.String())
+}
+
+func TestContextExpire_StaleWhileRevalidate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	cfg := &config.Config{CacheStaleWhileRevalidate: time.Minute}
+	srv := newTestServer(t, mockCache, cfg)
+
+	// Expired 30 seconds ago, still within the stale window
+	entry := &cachedResponse{
+		Status:   http.StatusOK,
+		Header:   http.Header{},
+		Body:     []byte(`old`),
+		StoredAt: time.Now().Add(-90 * time.Second),
+	}
+	refreshed := make(chan string, 1)
+	mockCache.EXPECT().Get(gomock.Any(), "user:123", gomock.Any()).Return(cachedEntry(t, entry), nil)
+	mockCache.EXPECT().Set(gomock.Any(), "user:123", gomock.Any(), 2*time.Minute).DoAndReturn(
+		func(ctx interface{}, key, value string, cacheTTL time.Duration) error {
+			refreshed <- value
+			return nil
+		})
+
+	handler := srv.contextExpire(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`new`))
+	}, generateUserCacheKey, time.Minute)
+
+	w := httptest.NewRecorder()
+	handler(w, userRequest())
+	assert.Equal(t, "STALE", w.Result().Header.Get("X-Cache"))
+	assert.Equal(t, "old", w.Body.String())
+
+	select {
+	case value := <-refreshed:
+		var stored cachedResponse
+		assert.NoError(t, json.Unmarshal([]byte(value), &stored))
+		assert.Equal(t, "new", string(stored.Body))
+	case <-time.After(time.Second):
+		t.Fatal("the entry was not refreshed in the background")
+	}
+}
@@ -12,6 +12,9 @@ type Router interface {
 	Post(string, http.HandlerFunc)
 	Delete(string, http.HandlerFunc)
 	Update(string, http.HandlerFunc)
+	Patch(string, http.HandlerFunc)
+	// Use adds a middleware that runs after the route is matched
+	Use(mux.MiddlewareFunc)
 }
 
 type router struct {
@@ -37,3 +40,11 @@ func (router *router) Delete(path string, handlerFunc http.HandlerFunc) {
 func (router *router) Update(path string, handlerFunc http.HandlerFunc) {
 	router.mux.HandleFunc(path, handlerFunc).Methods(http.MethodPut)
 }
+
+func (router *router) Patch(path string, handlerFunc http.HandlerFunc) {
+	router.mux.HandleFunc(path, handlerFunc).Methods(http.MethodPatch)
+}
+
+func (router *router) Use(middleware mux.MiddlewareFunc) {
+	router.mux.Use(middleware)
+}
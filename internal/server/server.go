@@ -1,83 +1,251 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
 	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
 	"gitlab.com/jkozhemiaka/web-layout/internal/handlers"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/mailer"
+	"gitlab.com/jkozhemiaka/web-layout/internal/metrics"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
 	"gitlab.com/jkozhemiaka/web-layout/internal/services"
+	"gitlab.com/jkozhemiaka/web-layout/internal/tracing"
 	myValidate "gitlab.com/jkozhemiaka/web-layout/internal/validate"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
 	"gitlab.com/jkozhemiaka/web-layout/internal/database"
+	"gitlab.com/jkozhemiaka/web-layout/internal/database/migrations"
 	"gorm.io/gorm"
 )
 
 type server struct {
-	db          *gorm.DB
-	cache       cache.CacheInterface
-	router      Router
-	logger      *zap.SugaredLogger
-	validator   *validator.Validate
-	cfg         *config.Config
-	userService services.UserServiceInterface
+	db                  *gorm.DB
+	migrator            *database.Migrator
+	cache               cache.CacheInterface
+	router              Router
+	logger              *zap.SugaredLogger
+	validator           *validator.Validate
+	cfg                 *config.Config
+	userService         services.UserServiceInterface
+	roleService         services.RoleServiceInterface
+	tokenService        auth.TokenServiceInterface
+	loginThrottler      auth.LoginThrottlerInterface
+	verificationService services.VerificationServiceInterface
+	passwordService     services.PasswordServiceInterface
+	privacyService      services.PrivacyServiceInterface
+	metrics             *metrics.Metrics
+	// responses coalesces concurrent requests for the same cached response
+	responses singleflight.Group
+	// shuttingDown makes /readyz fail while the server drains its traffic
+	shuttingDown atomic.Bool
 }
 
 func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	srv.router.ServeHttp(w, r)
+	srv.logRequests(http.HandlerFunc(srv.router.ServeHttp)).ServeHTTP(w, r)
 }
 
 func (srv *server) initializeRoutes() {
-	userHandler := handlers.NewUserHandler(srv.userService, srv.logger, srv.validator, srv.cfg)
-	loginHandler := handlers.NewLoginHandler(srv.userService, srv.logger, srv.cfg)
+	userHandler := handlers.NewUserHandler(srv.userService, srv.verificationService, srv.logger, srv.validator, srv.cfg)
+	loginHandler := handlers.NewLoginHandler(srv.userService, srv.tokenService, srv.loginThrottler, srv.logger, srv.cfg)
 	votesHandler := handlers.NewVotesHandler(srv.userService, srv.logger, srv.cfg)
+	roleHandler := handlers.NewRoleHandler(srv.roleService, srv.logger, srv.validator, srv.cfg)
+	passwordHandler := handlers.NewPasswordHandler(srv.passwordService, srv.logger, srv.validator, srv.cfg)
+	privacyHandler := handlers.NewPrivacyHandler(srv.privacyService, srv.logger, srv.cfg)
+	healthHandler := handlers.NewHealthHandler(srv.dependencyChecks(), srv.shuttingDown.Load, srv.logger, srv.cfg)
 
-	srv.router.Post("/users", srv.contextExpire(userHandler.CreateUserHandler, nil, time.Minute))
-	srv.router.Delete("/users/{id:[0-9]+}", srv.jwtMiddleware(userHandler.DeleteUser))
-	srv.router.Update("/users/{id:[0-9]+}", srv.jwtMiddleware(userHandler.UpdateUser))
+	srv.router.Use(srv.trace)
+	srv.router.Use(srv.logRoute)
+	srv.router.Use(srv.instrument)
 
-	srv.router.Get("/users", srv.contextExpire(userHandler.ListUsers, generateUsersListCacheKey, time.Minute))
+	srv.router.Post("/users", srv.contextExpire(srv.countSuccess(srv.metrics.UsersCreated, srv.invalidateUserCache("", userHandler.CreateUserHandler)), nil, time.Minute))
+	srv.router.Delete("/users/{id:[0-9]+}", srv.jwtMiddleware(srv.requirePermission(models.PermUsersDelete, srv.invalidateUserCache("id", userHandler.DeleteUser))))
+	srv.router.Update("/users/{id:[0-9]+}", srv.jwtMiddleware(srv.requirePermissionOrSelf(models.PermUsersUpdateAny, srv.invalidateUserCache("id", userHandler.UpdateUser))))
+	srv.router.Patch("/users/{id:[0-9]+}", srv.jwtMiddleware(srv.requirePermissionOrSelf(models.PermUsersUpdateAny, srv.invalidateUserCache("id", userHandler.PatchUser))))
+
+	srv.router.Get("/users/deleted", srv.jwtMiddleware(srv.requirePermission(models.PermUsersDelete, userHandler.ListDeletedUsers)))
+	srv.router.Post("/users/{id:[0-9]+}/restore", srv.jwtMiddleware(srv.requirePermission(models.PermUsersDelete, srv.invalidateUserCache("id", userHandler.RestoreUser))))
+	srv.router.Delete("/users/{id:[0-9]+}/purge", srv.jwtMiddleware(srv.requirePermission(models.PermUsersDelete, srv.invalidateUserCache("id", userHandler.PurgeUser))))
+
+	srv.router.Get("/users/{id:[0-9]+}/export", srv.jwtMiddleware(srv.requirePermissionOrSelf(models.PermUsersPrivacy, privacyHandler.ExportUserData)))
+	srv.router.Post("/users/{id:[0-9]+}/erase", srv.jwtMiddleware(srv.requirePermissionOrSelf(models.PermUsersPrivacy, srv.invalidateUserCache("id", privacyHandler.EraseUser))))
+
+	srv.router.Get("/users", srv.contextExpire(userHandler.ListUsers, generateUsersListCacheKey, time.Minute, constants.UsersListCacheTag))
 	srv.router.Get("/users/{id:[0-9]+}", srv.contextExpire(userHandler.GetUser, generateUserCacheKey, time.Minute))
-	srv.router.Get("/users/count", srv.contextExpire(userHandler.CountUsers, generateCountUsersCacheKey, time.Minute))
+	srv.router.Get("/users/count", srv.contextExpire(userHandler.CountUsers, generateCountUsersCacheKey, time.Minute, constants.UsersCountCacheTag))
+	srv.router.Get("/users/verify", userHandler.VerifyEmail)
+	srv.router.Post("/users/verify", userHandler.VerifyEmail)
+	srv.router.Post("/users/verify/resend", userHandler.ResendVerification)
 
-	srv.router.Post("/login", srv.contextExpire(loginHandler.Login, nil, time.Minute))
+	srv.router.Post("/login", srv.contextExpire(srv.countOutcome(srv.metrics.Logins, loginHandler.Login), nil, time.Minute))
+	srv.router.Post("/token/refresh", loginHandler.RefreshToken)
+	srv.router.Post("/logout", loginHandler.Logout)
+	srv.router.Post("/password/forgot", passwordHandler.ForgotPassword)
+	srv.router.Post("/password/reset", passwordHandler.ResetPassword)
+	srv.router.Update("/users/{id:[0-9]+}/password", srv.jwtMiddleware(srv.requirePermissionOrSelf(models.PermUsersUpdateAny, srv.invalidateUserCache("id", passwordHandler.ChangePassword))))
+	srv.router.Post("/users/{id:[0-9]+}/unlock", srv.jwtMiddleware(srv.requirePermission(models.PermUsersUpdateAny, loginHandler.UnlockAccount)))
+	srv.router.Get("/.well-known/jwks.json", loginHandler.JWKS)
+	srv.router.Get("/metrics", srv.metrics.Handler().ServeHTTP)
+	srv.router.Get("/healthz", healthHandler.Live)
+	srv.router.Get("/readyz", healthHandler.Ready)
 
-	srv.router.Post("/like/{id:[0-9]+}", srv.jwtMiddleware(votesHandler.Like))
-	srv.router.Post("/dislike/{id:[0-9]+}", srv.jwtMiddleware(votesHandler.Dislike))
-	srv.router.Delete("/revoke/{id:[0-9]+}", srv.jwtMiddleware(votesHandler.RevokeVote))
+	srv.router.Post("/like/{id:[0-9]+}", srv.jwtMiddleware(srv.countSuccess(srv.metrics.VotesCast.WithLabelValues("like"), srv.invalidateUserCache("id", votesHandler.Like))))
+	srv.router.Post("/dislike/{id:[0-9]+}", srv.jwtMiddleware(srv.countSuccess(srv.metrics.VotesCast.WithLabelValues("dislike"), srv.invalidateUserCache("id", votesHandler.Dislike))))
+	srv.router.Delete("/revoke/{id:[0-9]+}", srv.jwtMiddleware(srv.invalidateUserCache("id", votesHandler.RevokeVote)))
+	srv.router.Delete("/votes/{voter_id:[0-9]+}/{profile_id:[0-9]+}", srv.jwtMiddleware(srv.requirePermission(models.PermVotesModerate, srv.invalidateUserCache("profile_id", votesHandler.ModerateVote))))
+
+	srv.router.Get("/roles", srv.jwtMiddleware(srv.requirePermission(models.PermRolesManage, roleHandler.ListRoles)))
+	srv.router.Post("/roles", srv.jwtMiddleware(srv.requirePermission(models.PermRolesManage, roleHandler.CreateRole)))
+	srv.router.Delete("/roles/{id:[0-9]+}", srv.jwtMiddleware(srv.requirePermission(models.PermRolesManage, roleHandler.DeleteRole)))
+	srv.router.Update("/roles/{id:[0-9]+}/permissions", srv.jwtMiddleware(srv.requirePermission(models.PermRolesManage, roleHandler.SetRolePermissions)))
+	srv.router.Get("/permissions", srv.jwtMiddleware(srv.requirePermission(models.PermRolesManage, roleHandler.ListPermissions)))
 }
 
-func Run() {
-	logger, err := zap.NewDevelopment()
+// Server runs the HTTP API and owns the connections closed on shutdown
+type Server struct {
+	handler    *server
+	httpServer *http.Server
+	logger     *zap.SugaredLogger
+	cfg        *config.Config
+	// closers release the Redis connections and the DB pool once the requests
+	// are drained and flush the remaining spans, in this order
+	closers []func() error
+}
+
+// NewServer connects to the dependencies and builds the routes. Only the database
+// is required, the service starts with a degraded cache when Redis is down.
+func NewServer(cfg *config.Config, logger *zap.SugaredLogger, registry *prometheus.Registry) (*Server, error) {
+	metrics := metrics.New(registry)
+
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), cfg)
 	if err != nil {
-		log.Fatal(apperrors.LoggerInitError.AppendMessage(err))
+		return nil, err
+	}
+	tracing.Install(tracerProvider)
+	flushSpans := func() error {
+		return tracerProvider.Shutdown(context.Background())
 	}
-	defer logger.Sync()
 
-	cfg, err := config.NewConfig()
+	db, err := database.SetupDatabase(cfg)
 	if err != nil {
-		logger.Sugar().Fatal(err)
+		flushSpans()
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		flushSpans()
+		return nil, err
+	}
+	migrator, err := database.NewMigrator(db, migrations.FS, logger)
+	if err != nil {
+		sqlDB.Close()
+		flushSpans()
+		return nil, err
+	}
+	if cfg.MigrateOnStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			sqlDB.Close()
+			flushSpans()
+			return nil, err
+		}
+	}
+	for _, plugin := range []gorm.Plugin{metrics.NewGormPlugin(), tracing.NewGormPlugin()} {
+		if err := db.Use(plugin); err != nil {
+			sqlDB.Close()
+			flushSpans()
+			return nil, err
+		}
 	}
 
-	db, err := database.SetupDatabase(cfg)
+	redisClient, err := cache.NewRedisClient(cfg.RedisURL)
 	if err != nil {
-		logger.Sugar().Fatal(err)
+		sqlDB.Close()
+		flushSpans()
+		return nil, err
+	}
+	redisClient.Client.AddHook(tracing.NewRedisHook())
+	// Both caches share the breaker, while it is open responses are cached in
+	// process memory and the token state is unavailable
+	cacheBreaker := cache.NewCircuitBreaker(cfg.CacheBreakerThreshold, cfg.CacheBreakerCooldown, logger)
+	pingCtx, cancelPing := context.WithTimeout(context.Background(), cfg.CacheOpTimeout)
+	if err := redisClient.Ping(pingCtx); err != nil {
+		logger.Warnf("Redis is unavailable, starting with a degraded cache: %v", err)
+		cacheBreaker.Trip(err)
+	}
+	cancelPing()
+	remoteCache, err := cache.NewCache(cfg, redisClient, logger)
+	if err != nil {
+		redisClient.Client.Close()
+		sqlDB.Close()
+		flushSpans()
+		return nil, err
 	}
+	responseCache := cache.NewResilientCache(remoteCache, cache.NewMemoryCache(cfg.CacheLocalSize), cacheBreaker, cfg.CacheOpTimeout)
+	// Token state skips the tiered cache and has no memory fallback, a local
+	// copy could accept a refresh token another instance has just revoked
+	tokenCache := cache.NewResilientCache(redisClient, nil, cacheBreaker, cfg.CacheOpTimeout)
+	// Login throttling falls back to counting per instance, so logins keep working
+	throttleCache := cache.NewResilientCache(redisClient, cache.NewMemoryCache(cfg.CacheLocalSize), cacheBreaker, cfg.CacheOpTimeout)
 
-	cache := cache.NewRedisClient(cfg.RedisURL)
+	var closers []func() error
+	if tieredCache, ok := remoteCache.(*cache.TieredCache); ok {
+		closers = append(closers, func() error {
+			tieredCache.Close()
+			return nil
+		})
+	}
+	closers = append(closers, redisClient.Client.Close, sqlDB.Close, flushSpans)
+	closeAll := func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+
+	userRepo := repositories.NewUserRepo(db, logger)
+	voteRepo := repositories.NewVoteRepo(db, logger)
+	roleRepo := repositories.NewRoleRepo(db, logger)
+	userService := services.NewUserService(userRepo, voteRepo, responseCache, logger)
+	roleService := services.NewRoleService(roleRepo, logger)
+	keySet, err := auth.NewKeySet(cfg)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	tokenService := auth.NewTokenService(tokenCache, keySet, cfg)
+	loginThrottler := auth.NewLoginThrottler(throttleCache, cfg)
 
-	userRepo := repositories.NewUserRepo(db, logger.Sugar())
-	voteRepo := repositories.NewVoteRepo(db, logger.Sugar())
-	userService := services.NewUserService(userRepo, voteRepo, logger.Sugar())
+	mailer, err := mailer.NewMailer(cfg)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	verificationService := services.NewVerificationService(userRepo, tokenService, mailer, responseCache, logger, cfg)
+	passwordResetRepo := repositories.NewPasswordResetRepo(db, logger)
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepo(db, logger)
+	auditRepo := repositories.NewAuditRepo(db, logger)
+	passwordService := services.NewPasswordService(userRepo, passwordResetRepo, passwordHistoryRepo, auditRepo, mailer, logger, cfg)
+	privacyService := services.NewPrivacyService(repositories.NewPrivacyRepo(db, logger), auditRepo, responseCache, logger)
 
 	// Initialize validator
 	validate := validator.New()
@@ -85,27 +253,152 @@ func Run() {
 
 	srvRouter := &router{mux: mux.NewRouter()}
 	srv := &server{
-		db:          db,
-		cache:       cache,
-		router:      srvRouter,
-		logger:      logger.Sugar(),
-		validator:   validate,
-		cfg:         cfg,
-		userService: userService,
+		db:                  db,
+		migrator:            migrator,
+		cache:               responseCache,
+		router:              srvRouter,
+		logger:              logger,
+		validator:           validate,
+		cfg:                 cfg,
+		userService:         userService,
+		roleService:         roleService,
+		tokenService:        tokenService,
+		loginThrottler:      loginThrottler,
+		verificationService: verificationService,
+		passwordService:     passwordService,
+		privacyService:      privacyService,
+		metrics:             metrics,
 	}
 	srv.initializeRoutes()
 
-	logger.Sugar().Infof("Listening HTTP service on %s port", cfg.AppPort)
-	err = http.ListenAndServe(fmt.Sprintf(":%s", cfg.AppPort), srv)
+	return newServer(srv, closers), nil
+}
+
+func newServer(srv *server, closers []func() error) *Server {
+	return &Server{
+		handler: srv,
+		httpServer: &http.Server{
+			Addr:              fmt.Sprintf(":%s", srv.cfg.AppPort),
+			Handler:           srv,
+			ReadHeaderTimeout: srv.cfg.HTTPReadHeaderTimeout,
+			ReadTimeout:       srv.cfg.HTTPReadTimeout,
+			WriteTimeout:      srv.cfg.HTTPWriteTimeout,
+			IdleTimeout:       srv.cfg.HTTPIdleTimeout,
+		},
+		logger:  srv.logger,
+		cfg:     srv.cfg,
+		closers: closers,
+	}
+}
+
+// Start serves HTTP until Shutdown is called, it then returns nil
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(listener)
+}
+
+func (s *Server) serve(listener net.Listener) error {
+	s.logger.Infof("Listening HTTP service on %s", listener.Addr())
+	err := s.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown fails readiness for SHUTDOWN_READINESS_DELAY so load balancers take
+// the instance out, stops accepting connections and waits for the in-flight
+// requests. When ctx expires first the remaining connections are cut. The
+// Redis and database connections are closed last.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.handler.shuttingDown.Store(true)
+	s.logger.Infof("Shutting down, readiness is failing for %s", s.cfg.ShutdownReadinessDelay)
+	select {
+	case <-ctx.Done():
+	case <-time.After(s.cfg.ShutdownReadinessDelay):
+	}
+
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.logger.Errorf("In-flight requests were not drained in time: %v", err)
+		s.httpServer.Close()
+	}
+
+	for _, closer := range s.closers {
+		if closeErr := closer(); closeErr != nil {
+			s.logger.Error(closeErr)
+			err = errors.Join(err, closeErr)
+		}
+	}
+	return err
+}
+
+func Run() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := logging.NewLogger(cfg)
+	if err != nil {
+		log.Fatal(apperrors.LoggerInitError.AppendMessage(err))
+	}
+	defer logger.Sync()
+
+	srv, err := NewServer(cfg, logger.Sugar(), metrics.NewRegistry())
 	if err != nil {
 		logger.Sugar().Fatal(err)
 	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Start()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	select {
+	case err := <-serveErr:
+		logger.Sugar().Fatal(err)
+	case sig := <-stop:
+		logger.Sugar().Infof("Received %s", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownReadinessDelay+cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Sugar().Error(err)
+	}
+}
+
+// dependencyChecks lists what /readyz checks. Redis is not critical, the cache
+// falls back to process memory while it is down.
+func (srv *server) dependencyChecks() []handlers.DependencyCheck {
+	checks := []handlers.DependencyCheck{
+		{Name: "postgres", Critical: true, Check: srv.pingDatabase},
+		{Name: "migrations", Critical: true, Check: srv.migrator.CheckPending},
+	}
+	if pinger, ok := srv.cache.(cache.Pinger); ok {
+		checks = append(checks, handlers.DependencyCheck{Name: "redis", Check: pinger.Ping})
+	}
+	return checks
+}
+
+func (srv *server) pingDatabase(ctx context.Context) error {
+	sqlDB, err := srv.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
 
 // Функція для генерації ключа кешу для отримання користувача
 func generateUserCacheKey(r *http.Request) string {
 	vars := mux.Vars(r)
-	return "user:" + vars["id"]
+	return constants.UserCacheKeyPrefix + vars["id"]
 }
 
 // Функція для генерації ключа кешу для списку користувачів
@@ -113,9 +406,25 @@ func generateUsersListCacheKey(r *http.Request) string {
 	queryParams := r.URL.Query()
 	page := queryParams.Get("page")
 	pageSize := queryParams.Get("page_size")
-	return fmt.Sprintf("users_list_page_%s_size_%s", page, pageSize)
+	key := fmt.Sprintf("users_list_page_%s_size_%s", page, pageSize)
+
+	// Encode sorts the filters and escapes their values, so every combination
+	// gets its own key whatever the parameter order
+	filters := url.Values{}
+	for _, name := range usersListFilterParams {
+		if value := queryParams.Get(name); value != "" {
+			filters.Set(name, value)
+		}
+	}
+	if len(filters) > 0 {
+		key += "?" + filters.Encode()
+	}
+	return key
 }
 
+// usersListFilterParams are the query parameters of GET /users besides the paging
+var usersListFilterParams = []string{"role", "created_after", "created_before", "min_rating", "q", "sort"}
+
 // Функція для генерації ключа кешу для підрахунку користувачів
 func generateCountUsersCacheKey(r *http.Request) string {
 	return "count_users"
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+)
+
+func TestServer_ShutdownDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	srv := &server{
+		router: &router{mux: mux.NewRouter()},
+		logger: zaptest.NewLogger(t).Sugar(),
+		cfg:    &config.Config{ShutdownReadinessDelay: 50 * time.Millisecond},
+	}
+	srv.router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	var closed []string
+	s := newServer(srv, []func() error{
+		func() error { closed = append(closed, "redis"); return nil },
+		func() error { closed = append(closed, "db"); return nil },
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.serve(listener)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+	assert.True(t, srv.shuttingDown.Load())
+
+	// The request started before the shutdown got its full response
+	response := <-responses
+	assert.NoError(t, response.err)
+	assert.Equal(t, "done", response.body)
+	assert.NoError(t, <-serveErr)
+	assert.Equal(t, []string{"redis", "db"}, closed)
+
+	_, err = http.Get("http://" + listener.Addr().String() + "/slow")
+	assert.Error(t, err)
+}
+
+func TestGenerateUsersListCacheKey_CoversEveryParameter(t *testing.T) {
+	key := func(target string) string {
+		return generateUsersListCacheKey(httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, "users_list_page_1_size_10", key("/users?page=1&page_size=10&unknown=1"))
+	assert.Equal(t,
+		key("/users?page=1&page_size=10&sort=-rating&q=jane&role=admin"),
+		key("/users?role=admin&q=jane&page_size=10&sort=-rating&page=1"),
+	)
+
+	filtered := []string{
+		"/users?page=1&page_size=10&role=admin",
+		"/users?page=1&page_size=10&created_after=2024-01-01",
+		"/users?page=1&page_size=10&created_before=2024-01-01",
+		"/users?page=1&page_size=10&min_rating=3",
+		"/users?page=1&page_size=10&q=jane",
+		"/users?page=1&page_size=10&sort=rating",
+		"/users?page=1&page_size=10&q=jane%26role%3Dadmin",
+	}
+	seen := map[string]string{key("/users?page=1&page_size=10"): "/users?page=1&page_size=10"}
+	for _, target := range filtered {
+		k := key(target)
+		assert.NotContains(t, seen, k, "%s shares its cache key with %s", target, seen[k])
+		seen[k] = target
+	}
+}
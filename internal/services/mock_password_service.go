@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/password_service.go
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPasswordServiceInterface is a mock of PasswordServiceInterface interface.
+type MockPasswordServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordServiceInterfaceMockRecorder
+}
+
+// MockPasswordServiceInterfaceMockRecorder is the mock recorder for MockPasswordServiceInterface.
+type MockPasswordServiceInterfaceMockRecorder struct {
+	mock *MockPasswordServiceInterface
+}
+
+// NewMockPasswordServiceInterface creates a new mock instance.
+func NewMockPasswordServiceInterface(ctrl *gomock.Controller) *MockPasswordServiceInterface {
+	mock := &MockPasswordServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockPasswordServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordServiceInterface) EXPECT() *MockPasswordServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockPasswordServiceInterface) ChangePassword(ctx context.Context, actorID, userID uint, currentPassword, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, actorID, userID, currentPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockPasswordServiceInterfaceMockRecorder) ChangePassword(ctx, actorID, userID, currentPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockPasswordServiceInterface)(nil).ChangePassword), ctx, actorID, userID, currentPassword, newPassword)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockPasswordServiceInterface) RequestPasswordReset(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockPasswordServiceInterfaceMockRecorder) RequestPasswordReset(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockPasswordServiceInterface)(nil).RequestPasswordReset), ctx, email)
+}
+
+// ResetPassword mocks base method.
+func (m *MockPasswordServiceInterface) ResetPassword(ctx context.Context, token, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, token, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockPasswordServiceInterfaceMockRecorder) ResetPassword(ctx, token, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockPasswordServiceInterface)(nil).ResetPassword), ctx, token, newPassword)
+}
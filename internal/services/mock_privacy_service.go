@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/privacy_service.go
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockPrivacyServiceInterface is a mock of PrivacyServiceInterface interface.
+type MockPrivacyServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyServiceInterfaceMockRecorder
+}
+
+// MockPrivacyServiceInterfaceMockRecorder is the mock recorder for MockPrivacyServiceInterface.
+type MockPrivacyServiceInterfaceMockRecorder struct {
+	mock *MockPrivacyServiceInterface
+}
+
+// NewMockPrivacyServiceInterface creates a new mock instance.
+func NewMockPrivacyServiceInterface(ctrl *gomock.Controller) *MockPrivacyServiceInterface {
+	mock := &MockPrivacyServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockPrivacyServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyServiceInterface) EXPECT() *MockPrivacyServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// EraseUser mocks base method.
+func (m *MockPrivacyServiceInterface) EraseUser(ctx context.Context, actorID, userID uint) (*models.ErasureReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, actorID, userID)
+	ret0, _ := ret[0].(*models.ErasureReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockPrivacyServiceInterfaceMockRecorder) EraseUser(ctx, actorID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockPrivacyServiceInterface)(nil).EraseUser), ctx, actorID, userID)
+}
+
+// ExportUserData mocks base method.
+func (m *MockPrivacyServiceInterface) ExportUserData(ctx context.Context, actorID, userID uint) (*models.UserDataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, actorID, userID)
+	ret0, _ := ret[0].(*models.UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockPrivacyServiceInterfaceMockRecorder) ExportUserData(ctx, actorID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockPrivacyServiceInterface)(nil).ExportUserData), ctx, actorID, userID)
+}
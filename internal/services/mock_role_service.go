@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/role_service.go
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockRoleServiceInterface is a mock of RoleServiceInterface interface.
+type MockRoleServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleServiceInterfaceMockRecorder
+}
+
+// MockRoleServiceInterfaceMockRecorder is the mock recorder for MockRoleServiceInterface.
+type MockRoleServiceInterfaceMockRecorder struct {
+	mock *MockRoleServiceInterface
+}
+
+// NewMockRoleServiceInterface creates a new mock instance.
+func NewMockRoleServiceInterface(ctrl *gomock.Controller) *MockRoleServiceInterface {
+	mock := &MockRoleServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockRoleServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleServiceInterface) EXPECT() *MockRoleServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateRole mocks base method.
+func (m *MockRoleServiceInterface) CreateRole(ctx context.Context, name string, permissions []string) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, name, permissions)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockRoleServiceInterfaceMockRecorder) CreateRole(ctx, name, permissions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockRoleServiceInterface)(nil).CreateRole), ctx, name, permissions)
+}
+
+// DeleteRole mocks base method.
+func (m *MockRoleServiceInterface) DeleteRole(ctx context.Context, roleID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockRoleServiceInterfaceMockRecorder) DeleteRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockRoleServiceInterface)(nil).DeleteRole), ctx, roleID)
+}
+
+// GetRolePermissions mocks base method.
+func (m *MockRoleServiceInterface) GetRolePermissions(ctx context.Context, roleName string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolePermissions", ctx, roleName)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissions indicates an expected call of GetRolePermissions.
+func (mr *MockRoleServiceInterfaceMockRecorder) GetRolePermissions(ctx, roleName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolePermissions", reflect.TypeOf((*MockRoleServiceInterface)(nil).GetRolePermissions), ctx, roleName)
+}
+
+// ListPermissions mocks base method.
+func (m *MockRoleServiceInterface) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPermissions", ctx)
+	ret0, _ := ret[0].([]models.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPermissions indicates an expected call of ListPermissions.
+func (mr *MockRoleServiceInterfaceMockRecorder) ListPermissions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPermissions", reflect.TypeOf((*MockRoleServiceInterface)(nil).ListPermissions), ctx)
+}
+
+// ListRoles mocks base method.
+func (m *MockRoleServiceInterface) ListRoles(ctx context.Context) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", ctx)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockRoleServiceInterfaceMockRecorder) ListRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockRoleServiceInterface)(nil).ListRoles), ctx)
+}
+
+// SetRolePermissions mocks base method.
+func (m *MockRoleServiceInterface) SetRolePermissions(ctx context.Context, roleID uint, permissions []string) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRolePermissions", ctx, roleID, permissions)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRolePermissions indicates an expected call of SetRolePermissions.
+func (mr *MockRoleServiceInterfaceMockRecorder) SetRolePermissions(ctx, roleID, permissions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRolePermissions", reflect.TypeOf((*MockRoleServiceInterface)(nil).SetRolePermissions), ctx, roleID, permissions)
+}
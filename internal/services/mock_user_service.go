@@ -66,18 +66,33 @@ func (mr *MockUserServiceInterfaceMockRecorder) CreateUser(ctx, user interface{}
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserServiceInterface) DeleteUser(ctx context.Context, userID string) (*models.User, error) {
+func (m *MockUserServiceInterface) DeleteUser(ctx context.Context, userID string, version uint) (*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID, version)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserServiceInterfaceMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+func (mr *MockUserServiceInterfaceMockRecorder) DeleteUser(ctx, userID, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserServiceInterface)(nil).DeleteUser), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserServiceInterface)(nil).DeleteUser), ctx, userID, version)
+}
+
+// GetTokenVersion mocks base method.
+func (m *MockUserServiceInterface) GetTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", ctx, userID)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockUserServiceInterfaceMockRecorder) GetTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockUserServiceInterface)(nil).GetTokenVersion), ctx, userID)
 }
 
 // GetUser mocks base method.
@@ -110,19 +125,78 @@ func (mr *MockUserServiceInterfaceMockRecorder) GetUserByEmail(ctx, email interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserServiceInterface)(nil).GetUserByEmail), ctx, email)
 }
 
+// ListDeletedUsers mocks base method.
+func (m *MockUserServiceInterface) ListDeletedUsers(ctx context.Context, page, pageSize int) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeletedUsers", ctx, page, pageSize)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeletedUsers indicates an expected call of ListDeletedUsers.
+func (mr *MockUserServiceInterfaceMockRecorder) ListDeletedUsers(ctx, page, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeletedUsers", reflect.TypeOf((*MockUserServiceInterface)(nil).ListDeletedUsers), ctx, page, pageSize)
+}
+
 // ListUsers mocks base method.
-func (m *MockUserServiceInterface) ListUsers(ctx context.Context, page, pageSize int) ([]models.User, error) {
+func (m *MockUserServiceInterface) ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUsers", ctx, page, pageSize)
+	ret := m.ctrl.Call(m, "ListUsers", ctx, query)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListUsers indicates an expected call of ListUsers.
-func (mr *MockUserServiceInterfaceMockRecorder) ListUsers(ctx, page, pageSize interface{}) *gomock.Call {
+func (mr *MockUserServiceInterfaceMockRecorder) ListUsers(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserServiceInterface)(nil).ListUsers), ctx, query)
+}
+
+// PatchUser mocks base method.
+func (m *MockUserServiceInterface) PatchUser(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", ctx, userID, patch)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockUserServiceInterfaceMockRecorder) PatchUser(ctx, userID, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockUserServiceInterface)(nil).PatchUser), ctx, userID, patch)
+}
+
+// PurgeUser mocks base method.
+func (m *MockUserServiceInterface) PurgeUser(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeUser indicates an expected call of PurgeUser.
+func (mr *MockUserServiceInterfaceMockRecorder) PurgeUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUser", reflect.TypeOf((*MockUserServiceInterface)(nil).PurgeUser), ctx, userID)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserServiceInterface) RestoreUser(ctx context.Context, userID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, userID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserServiceInterfaceMockRecorder) RestoreUser(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserServiceInterface)(nil).ListUsers), ctx, page, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserServiceInterface)(nil).RestoreUser), ctx, userID)
 }
 
 // RevokeVote mocks base method.
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/verification_service.go
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "gitlab.com/jkozhemiaka/web-layout/internal/models"
+)
+
+// MockVerificationServiceInterface is a mock of VerificationServiceInterface interface.
+type MockVerificationServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerificationServiceInterfaceMockRecorder
+}
+
+// MockVerificationServiceInterfaceMockRecorder is the mock recorder for MockVerificationServiceInterface.
+type MockVerificationServiceInterfaceMockRecorder struct {
+	mock *MockVerificationServiceInterface
+}
+
+// NewMockVerificationServiceInterface creates a new mock instance.
+func NewMockVerificationServiceInterface(ctrl *gomock.Controller) *MockVerificationServiceInterface {
+	mock := &MockVerificationServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockVerificationServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerificationServiceInterface) EXPECT() *MockVerificationServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ResendVerificationEmail mocks base method.
+func (m *MockVerificationServiceInterface) ResendVerificationEmail(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerificationEmail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerificationEmail indicates an expected call of ResendVerificationEmail.
+func (mr *MockVerificationServiceInterfaceMockRecorder) ResendVerificationEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerificationEmail", reflect.TypeOf((*MockVerificationServiceInterface)(nil).ResendVerificationEmail), ctx, email)
+}
+
+// SendVerificationEmail mocks base method.
+func (m *MockVerificationServiceInterface) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationEmail", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationEmail indicates an expected call of SendVerificationEmail.
+func (mr *MockVerificationServiceInterfaceMockRecorder) SendVerificationEmail(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmail", reflect.TypeOf((*MockVerificationServiceInterface)(nil).SendVerificationEmail), ctx, user)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockVerificationServiceInterface) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockVerificationServiceInterfaceMockRecorder) VerifyEmail(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockVerificationServiceInterface)(nil).VerifyEmail), ctx, token)
+}
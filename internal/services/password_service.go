@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/mailer"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/passwords"
+	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
+	"go.uber.org/zap"
+)
+
+type PasswordService struct {
+	userRepo          repositories.UserRepoInterface
+	passwordResetRepo repositories.PasswordResetRepoInterface
+	historyRepo       repositories.PasswordHistoryRepoInterface
+	auditRepo         repositories.AuditRepoInterface
+	mailer            mailer.MailerInterface
+	logger            *zap.SugaredLogger
+	cfg               *config.Config
+}
+
+type PasswordServiceInterface interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	ChangePassword(ctx context.Context, actorID, userID uint, currentPassword, newPassword string) error
+}
+
+func NewPasswordService(userRepo repositories.UserRepoInterface, passwordResetRepo repositories.PasswordResetRepoInterface, historyRepo repositories.PasswordHistoryRepoInterface, auditRepo repositories.AuditRepoInterface, mailer mailer.MailerInterface, logger *zap.SugaredLogger, cfg *config.Config) PasswordServiceInterface {
+	return &PasswordService{
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		historyRepo:       historyRepo,
+		auditRepo:         auditRepo,
+		mailer:            mailer,
+		logger:            logger,
+		cfg:               cfg,
+	}
+}
+
+// RequestPasswordReset mails a reset link to the user. Unknown emails are ignored
+// without an error so callers can't tell whether an account exists.
+func (service *PasswordService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := service.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	err = service.passwordResetRepo.CreateToken(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(service.cfg.PasswordResetTTL),
+	})
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	link := fmt.Sprintf("%s/password/reset?token=%s", service.cfg.AppBaseURL, url.QueryEscape(token))
+	err = service.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\r\n\r\nsomeone asked to reset the password of your account. If it was you, open the link below:\r\n\r\n%s\r\n\r\nThe link expires in %s. If you didn't ask for it, just ignore this email.\r\n",
+			user.FirstName, link, service.cfg.PasswordResetTTL),
+	})
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword redeems the token and sets the new password. Changing the password
+// bumps the token version, so every existing session of the user is revoked.
+func (service *PasswordService) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	tokenHash := auth.HashOpaqueToken(token)
+	resetToken, err := service.passwordResetRepo.GetValidToken(ctx, tokenHash)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	user, err := service.userRepo.GetUser(ctx, strconv.FormatUint(uint64(resetToken.UserID), 10))
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	// The token is spent only once the password is accepted, so a refused one can be retried
+	err = service.checkPasswordHistory(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.passwordResetRepo.ConsumeToken(ctx, tokenHash)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	err = service.setPassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Links sent before this one must not work anymore
+	err = service.passwordResetRepo.InvalidateUserTokens(ctx, resetToken.UserID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ChangePassword sets a new password for the user. Users changing their own password
+// must confirm the current one. When someone else changes it (an administrator) the
+// current password is not asked for and the change is recorded in the audit log.
+func (service *PasswordService) ChangePassword(ctx context.Context, actorID, userID uint, currentPassword, newPassword string) error {
+	user, err := service.userRepo.GetUser(ctx, strconv.FormatUint(uint64(userID), 10))
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	self := actorID == userID
+	if self && !passwords.CheckPasswordHash(currentPassword, user.Password) {
+		return &apperrors.InvalidCurrentPasswordErr
+	}
+
+	err = service.checkPasswordHistory(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = service.setPassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	if !self {
+		err = service.auditRepo.CreateEntry(ctx, &models.AuditLog{
+			ActorID:      actorID,
+			TargetUserID: userID,
+			Action:       models.AuditPasswordChangedByAdmin,
+		})
+		if err != nil {
+			logging.FromContext(ctx, service.logger).Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPasswordHistory refuses the current password and the previous ones kept in the history
+func (service *PasswordService) checkPasswordHistory(ctx context.Context, user *models.User, newPassword string) error {
+	size := service.cfg.PasswordHistorySize
+	if size <= 0 {
+		return nil
+	}
+
+	if passwords.CheckPasswordHash(newPassword, user.Password) {
+		return &apperrors.PasswordReusedErr
+	}
+
+	hashes, err := service.historyRepo.ListRecentHashes(ctx, user.ID, size-1)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+	for _, hash := range hashes {
+		if passwords.CheckPasswordHash(newPassword, hash) {
+			return &apperrors.PasswordReusedErr
+		}
+	}
+
+	return nil
+}
+
+// setPassword stores the new password and moves the old hash to the history. Changing
+// the password bumps the token version, so every existing session of the user is revoked.
+func (service *PasswordService) setPassword(ctx context.Context, user *models.User, newPassword string) error {
+	hash, err := passwords.HashPassword(newPassword)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	_, err = service.userRepo.UpdateUser(ctx, strconv.FormatUint(uint64(user.ID), 10), &models.User{Password: hash})
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	if service.cfg.PasswordHistorySize > 1 && user.Password != "" {
+		err = service.historyRepo.AddHash(ctx, user.ID, user.Password, service.cfg.PasswordHistorySize-1)
+		if err != nil {
+			logging.FromContext(ctx, service.logger).Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/mailer"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/passwords"
+	mocks "gitlab.com/jkozhemiaka/web-layout/internal/repositories/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPasswordService_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	cfg := &config.Config{AppBaseURL: "http://localhost:50052", PasswordResetTTL: time.Hour}
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, cfg)
+
+	var storedHash string
+	var sentBody string
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "test@example.com").Return(&models.User{ID: 1, Email: "test@example.com"}, nil)
+	mockResetRepo.EXPECT().CreateToken(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, token *models.PasswordResetToken) error {
+		storedHash = token.TokenHash
+		assert.Equal(t, uint(1), token.UserID)
+		assert.True(t, token.ExpiresAt.After(time.Now()))
+		return nil
+	})
+	mockMailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg *mailer.Message) error {
+		sentBody = msg.Body
+		return nil
+	})
+
+	err := passwordService.RequestPasswordReset(context.Background(), "test@example.com")
+	assert.NoError(t, err)
+
+	// Only the hash is stored, the token itself is in the link
+	link := sentBody[strings.Index(sentBody, "token=")+len("token="):]
+	token := strings.Fields(link)[0]
+	assert.NotEqual(t, token, storedHash)
+	assert.Equal(t, auth.HashOpaqueToken(token), storedHash)
+}
+
+func TestPasswordService_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, &config.Config{})
+
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "unknown@example.com").Return(nil, nil)
+
+	err := passwordService.RequestPasswordReset(context.Background(), "unknown@example.com")
+	assert.NoError(t, err)
+}
+
+func TestPasswordService_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, &config.Config{})
+
+	mockResetRepo.EXPECT().GetValidToken(gomock.Any(), auth.HashOpaqueToken("reset-token")).Return(&models.PasswordResetToken{UserID: 1}, nil)
+	mockRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&models.User{ID: 1}, nil)
+	mockResetRepo.EXPECT().ConsumeToken(gomock.Any(), auth.HashOpaqueToken("reset-token")).Return(&models.PasswordResetToken{UserID: 1}, nil)
+	mockRepo.EXPECT().UpdateUser(gomock.Any(), "1", gomock.Any()).DoAndReturn(func(ctx context.Context, userID string, updatedData *models.User) (*models.User, error) {
+		assert.True(t, passwords.CheckPasswordHash("N3wPassword!", updatedData.Password))
+		return updatedData, nil
+	})
+	mockResetRepo.EXPECT().InvalidateUserTokens(gomock.Any(), uint(1)).Return(nil)
+
+	err := passwordService.ResetPassword(context.Background(), "reset-token", "N3wPassword!")
+	assert.NoError(t, err)
+}
+
+func TestPasswordService_ResetPassword_UsedToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, &config.Config{})
+
+	mockResetRepo.EXPECT().GetValidToken(gomock.Any(), gomock.Any()).Return(nil, &apperrors.InvalidResetTokenErr)
+
+	err := passwordService.ResetPassword(context.Background(), "reset-token", "N3wPassword!")
+	assert.True(t, apperrors.Is(err, &apperrors.InvalidResetTokenErr))
+}
+
+func TestPasswordService_ResetPassword_ReusedPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	cfg := &config.Config{PasswordHistorySize: 3}
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, cfg)
+
+	oldHash, err := passwords.HashPassword("0ldPassword!")
+	assert.NoError(t, err)
+
+	// The token is not consumed, so the user can try again with another password
+	mockResetRepo.EXPECT().GetValidToken(gomock.Any(), auth.HashOpaqueToken("reset-token")).Return(&models.PasswordResetToken{UserID: 1}, nil)
+	mockRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&models.User{ID: 1, Password: "current-hash"}, nil)
+	mockHistoryRepo.EXPECT().ListRecentHashes(gomock.Any(), uint(1), 2).Return([]string{oldHash}, nil)
+
+	err = passwordService.ResetPassword(context.Background(), "reset-token", "0ldPassword!")
+	assert.True(t, apperrors.Is(err, &apperrors.PasswordReusedErr))
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	cfg := &config.Config{PasswordHistorySize: 3}
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, cfg)
+
+	currentHash, err := passwords.HashPassword("0ldPassword!")
+	assert.NoError(t, err)
+
+	mockRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&models.User{ID: 1, Password: currentHash}, nil)
+	mockHistoryRepo.EXPECT().ListRecentHashes(gomock.Any(), uint(1), 2).Return(nil, nil)
+	mockRepo.EXPECT().UpdateUser(gomock.Any(), "1", gomock.Any()).DoAndReturn(func(ctx context.Context, userID string, updatedData *models.User) (*models.User, error) {
+		assert.True(t, passwords.CheckPasswordHash("N3wPassword!", updatedData.Password))
+		return updatedData, nil
+	})
+	mockHistoryRepo.EXPECT().AddHash(gomock.Any(), uint(1), currentHash, 2).Return(nil)
+
+	err = passwordService.ChangePassword(context.Background(), 1, 1, "0ldPassword!", "N3wPassword!")
+	assert.NoError(t, err)
+}
+
+func TestPasswordService_ChangePassword_WrongCurrentPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, &config.Config{})
+
+	currentHash, err := passwords.HashPassword("0ldPassword!")
+	assert.NoError(t, err)
+
+	mockRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&models.User{ID: 1, Password: currentHash}, nil)
+
+	err = passwordService.ChangePassword(context.Background(), 1, 1, "wrong", "N3wPassword!")
+	assert.True(t, apperrors.Is(err, &apperrors.InvalidCurrentPasswordErr))
+}
+
+func TestPasswordService_ChangePassword_ReusedPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	cfg := &config.Config{PasswordHistorySize: 3}
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, cfg)
+
+	currentHash, err := passwords.HashPassword("0ldPassword!")
+	assert.NoError(t, err)
+	previousHash, err := passwords.HashPassword("Pr3viousPassword!")
+	assert.NoError(t, err)
+
+	// An administrator doesn't need the current password but the history still applies
+	mockRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&models.User{ID: 1, Password: currentHash}, nil)
+	mockHistoryRepo.EXPECT().ListRecentHashes(gomock.Any(), uint(1), 2).Return([]string{previousHash}, nil)
+
+	err = passwordService.ChangePassword(context.Background(), 2, 1, "", "Pr3viousPassword!")
+	assert.True(t, apperrors.Is(err, &apperrors.PasswordReusedErr))
+}
+
+func TestPasswordService_ChangePassword_ByAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetRepoInterface(ctrl)
+	mockHistoryRepo := mocks.NewMockPasswordHistoryRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	passwordService := NewPasswordService(mockRepo, mockResetRepo, mockHistoryRepo, mockAuditRepo, mockMailer, mockLogger, &config.Config{})
+
+	mockRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&models.User{ID: 1, Password: "hash"}, nil)
+	mockRepo.EXPECT().UpdateUser(gomock.Any(), "1", gomock.Any()).Return(&models.User{ID: 1}, nil)
+	mockAuditRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry *models.AuditLog) error {
+		assert.Equal(t, uint(2), entry.ActorID)
+		assert.Equal(t, uint(1), entry.TargetUserID)
+		assert.Equal(t, models.AuditPasswordChangedByAdmin, entry.Action)
+		return nil
+	})
+
+	err := passwordService.ChangePassword(context.Background(), 2, 1, "", "N3wPassword!")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// PrivacyService answers data subject requests: the export and the erasure of
+// the personal data of a user
+type PrivacyService struct {
+	privacyRepo repositories.PrivacyRepoInterface
+	auditRepo   repositories.AuditRepoInterface
+	cache       cache.CacheInterface
+	logger      *zap.SugaredLogger
+}
+
+type PrivacyServiceInterface interface {
+	ExportUserData(ctx context.Context, actorID, userID uint) (*models.UserDataExport, error)
+	EraseUser(ctx context.Context, actorID, userID uint) (*models.ErasureReceipt, error)
+}
+
+func NewPrivacyService(privacyRepo repositories.PrivacyRepoInterface, auditRepo repositories.AuditRepoInterface, cache cache.CacheInterface, logger *zap.SugaredLogger) PrivacyServiceInterface {
+	return &PrivacyService{
+		privacyRepo: privacyRepo,
+		auditRepo:   auditRepo,
+		cache:       cache,
+		logger:      logger,
+	}
+}
+
+// ExportUserData assembles everything stored about the user. Every export is
+// recorded in the audit log, after it was assembled.
+func (service *PrivacyService) ExportUserData(ctx context.Context, actorID, userID uint) (*models.UserDataExport, error) {
+	ctx, span := tracer.Start(ctx, "PrivacyService.ExportUserData")
+	defer span.End()
+
+	export, err := service.privacyRepo.ExportUser(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	err = service.auditRepo.CreateEntry(ctx, &models.AuditLog{
+		ActorID:      actorID,
+		TargetUserID: userID,
+		Action:       models.AuditPersonalDataExported,
+	})
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// EraseUser anonymizes the user for good and returns the erasure receipt. The
+// user can't sign in afterwards and can't be restored.
+func (service *PrivacyService) EraseUser(ctx context.Context, actorID, userID uint) (*models.ErasureReceipt, error) {
+	ctx, span := tracer.Start(ctx, "PrivacyService.EraseUser")
+	defer span.End()
+
+	receipt, profileIDs, err := service.privacyRepo.EraseUser(ctx, actorID, userID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+	evictCachedProfiles(ctx, service.cache, service.logger, profileIDs)
+
+	logging.FromContext(ctx, service.logger).Infow("Erased the personal data of a user",
+		"target_user_id", userID,
+		"receipt_id", receipt.ID,
+	)
+	return receipt, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	mocks "gitlab.com/jkozhemiaka/web-layout/internal/repositories/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrivacyService_ExportUserData_RecordsAuditEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrivacyRepo := mocks.NewMockPrivacyRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	privacyService := NewPrivacyService(mockPrivacyRepo, mockAuditRepo, cache.NewMockCacheInterface(ctrl), zaptest.NewLogger(t).Sugar())
+
+	export := &models.UserDataExport{Profile: models.User{ID: 5}, VotesCast: []models.Vote{{UserID: 5, ProfileID: 6, Value: 1}}}
+	mockPrivacyRepo.EXPECT().ExportUser(gomock.Any(), uint(5)).Return(export, nil)
+	mockAuditRepo.EXPECT().CreateEntry(gomock.Any(), &models.AuditLog{
+		ActorID:      1,
+		TargetUserID: 5,
+		Action:       models.AuditPersonalDataExported,
+	}).Return(nil)
+
+	result, err := privacyService.ExportUserData(context.Background(), 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, export, result)
+}
+
+func TestPrivacyService_ExportUserData_UnknownUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrivacyRepo := mocks.NewMockPrivacyRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	privacyService := NewPrivacyService(mockPrivacyRepo, mockAuditRepo, cache.NewMockCacheInterface(ctrl), zaptest.NewLogger(t).Sugar())
+
+	// Nothing is exported, so nothing is audited
+	mockPrivacyRepo.EXPECT().ExportUser(gomock.Any(), uint(5)).Return(nil, apperrors.NoRecordFoundErr.AppendMessage("User not found."))
+
+	_, err := privacyService.ExportUserData(context.Background(), 1, 5)
+	assert.True(t, apperrors.Is(err, &apperrors.NoRecordFoundErr))
+}
+
+func TestPrivacyService_EraseUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrivacyRepo := mocks.NewMockPrivacyRepoInterface(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepoInterface(ctrl)
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	privacyService := NewPrivacyService(mockPrivacyRepo, mockAuditRepo, mockCache, zaptest.NewLogger(t).Sugar())
+
+	receipt := &models.ErasureReceipt{ID: 3, UserID: 5, ActorID: 5, VotesCastRemoved: 2, ProfilesRecalculated: 2}
+	mockPrivacyRepo.EXPECT().EraseUser(gomock.Any(), uint(5), uint(5)).Return(receipt, []uint{8, 9}, nil)
+	mockPrivacyRepo.EXPECT().EraseUser(gomock.Any(), uint(5), uint(5)).Return(nil, nil, &apperrors.UserErasedErr)
+	// The profiles the user voted for show another rating now
+	mockCache.EXPECT().Delete(gomock.Any(), "user:8", "user:9").Return(nil)
+
+	result, err := privacyService.EraseUser(context.Background(), 5, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, receipt, result)
+
+	_, err = privacyService.EraseUser(context.Background(), 5, 5)
+	assert.True(t, apperrors.Is(err, &apperrors.UserErasedErr))
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
+	"go.uber.org/zap"
+)
+
+type RoleService struct {
+	roleRepo repositories.RoleRepoInterface
+	logger   *zap.SugaredLogger
+}
+
+type RoleServiceInterface interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	CreateRole(ctx context.Context, name string, permissions []string) (*models.Role, error)
+	DeleteRole(ctx context.Context, roleID uint) error
+	SetRolePermissions(ctx context.Context, roleID uint, permissions []string) (*models.Role, error)
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	GetRolePermissions(ctx context.Context, roleName string) ([]string, error)
+}
+
+func NewRoleService(roleRepo repositories.RoleRepoInterface, logger *zap.SugaredLogger) RoleServiceInterface {
+	return &RoleService{
+		roleRepo: roleRepo,
+		logger:   logger,
+	}
+}
+
+func (service *RoleService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.ListRoles")
+	defer span.End()
+
+	roles, err := service.roleRepo.ListRoles(ctx)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (service *RoleService) CreateRole(ctx context.Context, name string, permissions []string) (*models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.CreateRole")
+	defer span.End()
+
+	resolved, err := service.resolvePermissions(ctx, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := service.roleRepo.CreateRole(ctx, &models.Role{Name: name, Permissions: resolved})
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (service *RoleService) DeleteRole(ctx context.Context, roleID uint) error {
+	ctx, span := tracer.Start(ctx, "RoleService.DeleteRole")
+	defer span.End()
+
+	err := service.roleRepo.DeleteRole(ctx, roleID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (service *RoleService) SetRolePermissions(ctx context.Context, roleID uint, permissions []string) (*models.Role, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.SetRolePermissions")
+	defer span.End()
+
+	resolved, err := service.resolvePermissions(ctx, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := service.roleRepo.SetRolePermissions(ctx, roleID, resolved)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (service *RoleService) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.ListPermissions")
+	defer span.End()
+
+	permissions, err := service.roleRepo.ListPermissions(ctx)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// GetRolePermissions returns the names of the permissions granted to the role
+func (service *RoleService) GetRolePermissions(ctx context.Context, roleName string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "RoleService.GetRolePermissions")
+	defer span.End()
+
+	permissions, err := service.roleRepo.GetRolePermissionNames(ctx, roleName)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// resolvePermissions maps permission names to records and fails on unknown names
+func (service *RoleService) resolvePermissions(ctx context.Context, names []string) ([]models.Permission, error) {
+	permissions, err := service.roleRepo.GetPermissionsByNames(ctx, names)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		known[permission.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return nil, apperrors.UnknownPermissionErr.AppendMessage(name)
+		}
+	}
+
+	return permissions, nil
+}
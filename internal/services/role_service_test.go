@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	mocks "gitlab.com/jkozhemiaka/web-layout/internal/repositories/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRoleService_CreateRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRoleRepoInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	roleService := NewRoleService(mockRepo, mockLogger)
+
+	permissions := []models.Permission{{ID: 4, Name: models.PermVotesModerate}}
+	mockRepo.EXPECT().GetPermissionsByNames(gomock.Any(), []string{models.PermVotesModerate}).Return(permissions, nil)
+	mockRepo.EXPECT().CreateRole(gomock.Any(), &models.Role{Name: "support", Permissions: permissions}).
+		DoAndReturn(func(ctx context.Context, role *models.Role) (*models.Role, error) {
+			role.ID = 4
+			return role, nil
+		})
+
+	role, err := roleService.CreateRole(context.Background(), "support", []string{models.PermVotesModerate})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), role.ID)
+}
+
+func TestRoleService_SetRolePermissions_UnknownPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRoleRepoInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	roleService := NewRoleService(mockRepo, mockLogger)
+
+	names := []string{models.PermUsersDelete, "users:fly"}
+	mockRepo.EXPECT().GetPermissionsByNames(gomock.Any(), names).Return([]models.Permission{{ID: 1, Name: models.PermUsersDelete}}, nil)
+
+	_, err := roleService.SetRolePermissions(context.Background(), 2, names)
+	assert.True(t, apperrors.Is(err, &apperrors.UnknownPermissionErr))
+}
+
+func TestRoleService_GetRolePermissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRoleRepoInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	roleService := NewRoleService(mockRepo, mockLogger)
+
+	mockRepo.EXPECT().GetRolePermissionNames(gomock.Any(), models.StrModerator).Return([]string{models.PermVotesModerate}, nil)
+
+	permissions, err := roleService.GetRolePermissions(context.Background(), models.StrModerator)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.PermVotesModerate}, permissions)
+}
@@ -2,46 +2,64 @@ package services
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
 	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
+	"gitlab.com/jkozhemiaka/web-layout/internal/tracing"
+	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer(tracing.InstrumentationName)
+
 type UserService struct {
 	userRepo repositories.UserRepoInterface
 	voteRepo repositories.VoteRepoInterface
+	cache    cache.CacheInterface
 	logger   *zap.SugaredLogger
 }
 
 type UserServiceInterface interface {
 	CreateUser(ctx context.Context, user *models.User) (uint, error)
-	DeleteUser(ctx context.Context, userID string) (*models.User, error)
+	DeleteUser(ctx context.Context, userID string, version uint) (*models.User, error)
 	GetUser(ctx context.Context, userID string) (*models.User, error)
 	UpdateUser(ctx context.Context, userID string, user *models.User) (*models.User, error)
-	ListUsers(ctx context.Context, page, pageSize int) ([]models.User, error)
+	PatchUser(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error)
+	ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.User, error)
 	CountUsers(ctx context.Context) (int, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	Vote(ctx context.Context, vote *models.Vote) (uint, error)
 	RevokeVote(ctx context.Context, userID uint, profileID uint) error
+	GetTokenVersion(ctx context.Context, userID uint) (uint, error)
+	ListDeletedUsers(ctx context.Context, page, pageSize int) ([]models.User, error)
+	RestoreUser(ctx context.Context, userID string) (*models.User, error)
+	PurgeUser(ctx context.Context, userID string) error
 }
 
-func NewUserService(userRepo repositories.UserRepoInterface, voteRepo repositories.VoteRepoInterface, logger *zap.SugaredLogger) UserServiceInterface {
+func NewUserService(userRepo repositories.UserRepoInterface, voteRepo repositories.VoteRepoInterface, cache cache.CacheInterface, logger *zap.SugaredLogger) UserServiceInterface {
 	return &UserService{
 		userRepo: userRepo,
 		voteRepo: voteRepo,
+		cache:    cache,
 		logger:   logger,
 	}
 }
 
 func (service *UserService) CreateUser(ctx context.Context, user *models.User) (userId uint, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	insertedUser, err := service.userRepo.CreateUser(ctx, user)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
 		return 0, err
 	}
 
@@ -49,19 +67,25 @@ func (service *UserService) CreateUser(ctx context.Context, user *models.User) (
 }
 
 func (service *UserService) GetUser(ctx context.Context, userID string) (user *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
 	user, err = service.userRepo.GetUser(ctx, userID)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (service *UserService) DeleteUser(ctx context.Context, userID string) (user *models.User, err error) {
-	user, err = service.userRepo.DeleteUser(ctx, userID)
+func (service *UserService) DeleteUser(ctx context.Context, userID string, version uint) (user *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	user, err = service.userRepo.DeleteUser(ctx, userID, version)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
 		return nil, err
 	}
 
@@ -69,19 +93,38 @@ func (service *UserService) DeleteUser(ctx context.Context, userID string) (user
 }
 
 func (service *UserService) UpdateUser(ctx context.Context, userID string, updatedData *models.User) (user *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
 	user, err = service.userRepo.UpdateUser(ctx, userID, updatedData)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (service *UserService) ListUsers(ctx context.Context, page, pageSize int) (user []models.User, err error) {
-	user, err = service.userRepo.ListUsers(ctx, page, pageSize)
+func (service *UserService) PatchUser(ctx context.Context, userID string, patch *models.UserPatch) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.PatchUser")
+	defer span.End()
+
+	user, err := service.userRepo.PatchUser(ctx, userID, patch)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (service *UserService) ListUsers(ctx context.Context, query *models.UserListQuery) (user []models.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
+	user, err = service.userRepo.ListUsers(ctx, query)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
 		return nil, err
 	}
 
@@ -89,9 +132,12 @@ func (service *UserService) ListUsers(ctx context.Context, page, pageSize int) (
 }
 
 func (service *UserService) CountUsers(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "UserService.CountUsers")
+	defer span.End()
+
 	count, err := service.userRepo.CountUsers(ctx)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
 		return 0, err
 	}
 
@@ -99,9 +145,12 @@ func (service *UserService) CountUsers(ctx context.Context) (int, error) {
 }
 
 func (service *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+
 	user, err := service.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		service.logger.Error(err)
+		logging.FromContext(ctx, service.logger).Error(err)
 		return nil, err
 	}
 
@@ -109,11 +158,14 @@ func (service *UserService) GetUserByEmail(ctx context.Context, email string) (*
 }
 
 func (service *UserService) Vote(ctx context.Context, vote *models.Vote) (uint, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Vote")
+	defer span.End()
+
 	// Get the user profile
 	var user *models.User
 	user, err := service.userRepo.GetUserByID(ctx, vote.UserID)
 	if err != nil {
-		service.logger.Error("Failed to get user", zap.Error(err))
+		logging.FromContext(ctx, service.logger).Error("Failed to get user", zap.Error(err))
 		return 0, apperrors.InsertionFailedErr.AppendMessage(err.Error())
 	}
 
@@ -122,10 +174,17 @@ func (service *UserService) Vote(ctx context.Context, vote *models.Vote) (uint,
 		return 0, &apperrors.VoteCooldownErr
 	}
 
+	// Deleted profiles can't be voted for
+	_, err = service.userRepo.GetUserByID(ctx, vote.ProfileID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Warn("Failed to get voted profile", zap.Error(err))
+		return 0, err
+	}
+
 	// Check if the user has already voted for this profile
 	existingVote, err := service.voteRepo.GetVote(ctx, vote.UserID, vote.ProfileID)
 	if err != nil && err != gorm.ErrRecordNotFound {
-		service.logger.Error("Failed to check existing vote", zap.Error(err))
+		logging.FromContext(ctx, service.logger).Error("Failed to check existing vote", zap.Error(err))
 		return 0, apperrors.InsertionFailedErr.AppendMessage(err.Error())
 	}
 
@@ -134,7 +193,7 @@ func (service *UserService) Vote(ctx context.Context, vote *models.Vote) (uint,
 		existingVote.Value = vote.Value
 		_, err = service.voteRepo.UpdateVote(ctx, existingVote)
 		if err != nil {
-			service.logger.Error("Failed to update vote", zap.Error(err))
+			logging.FromContext(ctx, service.logger).Error("Failed to update vote", zap.Error(err))
 			return 0, apperrors.UpdateFailedErr.AppendMessage(err.Error())
 		}
 		return existingVote.ID, nil
@@ -143,7 +202,7 @@ func (service *UserService) Vote(ctx context.Context, vote *models.Vote) (uint,
 	// Create new vote
 	insertedVote, err := service.voteRepo.CreateVote(ctx, vote)
 	if err != nil {
-		service.logger.Error("Failed to create vote", zap.Error(err))
+		logging.FromContext(ctx, service.logger).Error("Failed to create vote", zap.Error(err))
 		return 0, apperrors.InsertionFailedErr.AppendMessage(err.Error())
 	}
 
@@ -151,6 +210,9 @@ func (service *UserService) Vote(ctx context.Context, vote *models.Vote) (uint,
 }
 
 func (service *UserService) RevokeVote(ctx context.Context, userID uint, profileID uint) error {
+	ctx, span := tracer.Start(ctx, "UserService.RevokeVote")
+	defer span.End()
+
 	// Proceed to delete the vote
 	err := service.voteRepo.DeleteVote(ctx, userID, profileID)
 	if err != nil {
@@ -159,3 +221,75 @@ func (service *UserService) RevokeVote(ctx context.Context, userID uint, profile
 
 	return nil
 }
+
+// GetTokenVersion returns the current token version of a live user
+func (service *UserService) GetTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetTokenVersion")
+	defer span.End()
+
+	version, err := service.userRepo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func (service *UserService) ListDeletedUsers(ctx context.Context, page, pageSize int) ([]models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ListDeletedUsers")
+	defer span.End()
+
+	users, err := service.userRepo.ListDeletedUsers(ctx, page, pageSize)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (service *UserService) RestoreUser(ctx context.Context, userID string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.RestoreUser")
+	defer span.End()
+
+	user, err := service.userRepo.RestoreUser(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (service *UserService) PurgeUser(ctx context.Context, userID string) error {
+	ctx, span := tracer.Start(ctx, "UserService.PurgeUser")
+	defer span.End()
+
+	profileIDs, err := service.userRepo.PurgeUser(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	evictCachedProfiles(ctx, service.cache, service.logger, profileIDs)
+	return nil
+}
+
+// evictCachedProfiles drops the cached responses of profiles whose rating changed
+// without a request to their own route. A failure is only logged, the entries
+// expire with their TTL.
+func evictCachedProfiles(ctx context.Context, responseCache cache.CacheInterface, logger *zap.SugaredLogger, profileIDs []uint) {
+	if len(profileIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, len(profileIDs))
+	for i, profileID := range profileIDs {
+		keys[i] = constants.UserCacheKeyPrefix + strconv.FormatUint(uint64(profileID), 10)
+	}
+	err := responseCache.Delete(ctx, keys...)
+	if err != nil {
+		logging.FromContext(ctx, logger).Error(err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
 	"gitlab.com/jkozhemiaka/web-layout/internal/models"
 	mocks "gitlab.com/jkozhemiaka/web-layout/internal/repositories/mocks"
 
@@ -22,7 +23,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testUser := &models.User{Email: "test@example.com"}
 	mockRepo.EXPECT().CreateUser(gomock.Any(), testUser).Return(testUser, nil)
@@ -39,7 +40,7 @@ func TestUserService_GetUser(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testUserID := "1"
 	testUser := &models.User{ID: 1, Email: "test@example.com"}
@@ -57,13 +58,13 @@ func TestUserService_DeleteUser(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testUserID := "1"
 	testUser := &models.User{ID: 1, Email: "test@example.com"}
-	mockRepo.EXPECT().DeleteUser(gomock.Any(), testUserID).Return(testUser, nil)
+	mockRepo.EXPECT().DeleteUser(gomock.Any(), testUserID, uint(0)).Return(testUser, nil)
 
-	user, err := userService.DeleteUser(context.Background(), testUserID)
+	user, err := userService.DeleteUser(context.Background(), testUserID, 0)
 	assert.NoError(t, err)
 	assert.Equal(t, testUser, user)
 }
@@ -75,7 +76,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testUserID := "1"
 	testUser := &models.User{ID: 1, Email: "updated@example.com"}
@@ -93,15 +94,16 @@ func TestUserService_ListUsers(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testUsers := []models.User{
 		{ID: 1, Email: "user1@example.com"},
 		{ID: 2, Email: "user2@example.com"},
 	}
-	mockRepo.EXPECT().ListUsers(gomock.Any(), 1, 10).Return(testUsers, nil)
+	query := &models.UserListQuery{Page: 1, PageSize: 10}
+	mockRepo.EXPECT().ListUsers(gomock.Any(), query).Return(testUsers, nil)
 
-	users, err := userService.ListUsers(context.Background(), 1, 10)
+	users, err := userService.ListUsers(context.Background(), query)
 	assert.NoError(t, err)
 	assert.Equal(t, testUsers, users)
 }
@@ -113,7 +115,7 @@ func TestUserService_CountUsers(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	mockRepo.EXPECT().CountUsers(gomock.Any()).Return(2, nil)
 
@@ -129,7 +131,7 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testEmail := "test@example.com"
 	testUser := &models.User{ID: 1, Email: testEmail}
@@ -147,13 +149,14 @@ func TestUserService_Vote(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testVote := &models.Vote{UserID: 1, ProfileID: 2, Value: 1}
 	testUser := &models.User{ID: 1, VoteUpdatedAt: time.Now().Add(-2 * time.Hour)}
 
 	// Return the user and nil for error
 	mockRepo.EXPECT().GetUserByID(gomock.Any(), testVote.UserID).Return(testUser, nil)
+	mockRepo.EXPECT().GetUserByID(gomock.Any(), testVote.ProfileID).Return(&models.User{ID: 2}, nil)
 	mockVote.EXPECT().GetVote(gomock.Any(), testVote.UserID, testVote.ProfileID).Return(nil, nil)
 	mockVote.EXPECT().CreateVote(gomock.Any(), testVote).Return(testVote, nil)
 
@@ -169,7 +172,7 @@ func TestUserService_Vote_CooldownError(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testVote := &models.Vote{UserID: 1, ProfileID: 2, Value: 1}
 	testUser := &models.User{ID: 1, VoteUpdatedAt: time.Now().Add(-30 * time.Minute)} // Time within cooldown period
@@ -192,7 +195,7 @@ func TestUserService_Vote_UpdateSuccess(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testVote := &models.Vote{UserID: 1, ProfileID: 2, Value: 1}
 	existingVote := &models.Vote{ID: 10, UserID: 1, ProfileID: 2, Value: 0}
@@ -200,6 +203,7 @@ func TestUserService_Vote_UpdateSuccess(t *testing.T) {
 
 	// Set expectations
 	mockRepo.EXPECT().GetUserByID(gomock.Any(), testVote.UserID).Return(testUser, nil)
+	mockRepo.EXPECT().GetUserByID(gomock.Any(), testVote.ProfileID).Return(&models.User{ID: 2}, nil)
 	mockVote.EXPECT().GetVote(gomock.Any(), testVote.UserID, testVote.ProfileID).Return(existingVote, nil)
 	mockVote.EXPECT().UpdateVote(gomock.Any(), existingVote).Return(existingVote, nil)
 
@@ -208,6 +212,27 @@ func TestUserService_Vote_UpdateSuccess(t *testing.T) {
 	assert.Equal(t, existingVote.ID, voteID)
 }
 
+func TestUserService_Vote_DeletedProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
+
+	testVote := &models.Vote{UserID: 1, ProfileID: 2, Value: 1}
+	testUser := &models.User{ID: 1, VoteUpdatedAt: time.Now().Add(-2 * time.Hour)}
+
+	// The repository leaves soft deleted users out, no vote is stored
+	mockRepo.EXPECT().GetUserByID(gomock.Any(), testVote.UserID).Return(testUser, nil)
+	mockRepo.EXPECT().GetUserByID(gomock.Any(), testVote.ProfileID).Return(nil, apperrors.NoRecordFoundErr.AppendMessage("User not found."))
+
+	voteID, err := userService.Vote(context.Background(), testVote)
+	assert.True(t, apperrors.Is(err, &apperrors.NoRecordFoundErr))
+	assert.Equal(t, uint(0), voteID)
+}
+
 func TestUserService_Vote_GetUserError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -215,7 +240,7 @@ func TestUserService_Vote_GetUserError(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	testVote := &models.Vote{UserID: 1, ProfileID: 2, Value: 1}
 
@@ -235,7 +260,7 @@ func TestUserService_RevokeVote_Success(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	userID := uint(1)
 	profileID := uint(2)
@@ -254,7 +279,7 @@ func TestUserService_RevokeVote_DeleteVoteError(t *testing.T) {
 	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
 	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
 	mockLogger := zaptest.NewLogger(t).Sugar()
-	userService := NewUserService(mockRepo, mockVote, mockLogger)
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
 
 	userID := uint(1)
 	profileID := uint(2)
@@ -265,3 +290,52 @@ func TestUserService_RevokeVote_DeleteVoteError(t *testing.T) {
 	err := userService.RevokeVote(context.Background(), userID, profileID)
 	assert.Error(t, err)
 }
+
+func TestUserService_GetTokenVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
+
+	mockRepo.EXPECT().GetTokenVersion(gomock.Any(), uint(1)).Return(uint(3), nil)
+
+	version, err := userService.GetTokenVersion(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), version)
+}
+
+func TestUserService_GetTokenVersion_DeletedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	userService := NewUserService(mockRepo, mockVote, cache.NewMockCacheInterface(ctrl), mockLogger)
+
+	mockRepo.EXPECT().GetTokenVersion(gomock.Any(), uint(1)).Return(uint(0), apperrors.NoRecordFoundErr.AppendMessage("User not found."))
+
+	_, err := userService.GetTokenVersion(context.Background(), 1)
+	assert.True(t, apperrors.Is(err, &apperrors.NoRecordFoundErr))
+}
+
+func TestUserService_PurgeUser_EvictsVotedProfiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockVote := mocks.NewMockVoteRepoInterface(ctrl)
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	userService := NewUserService(mockRepo, mockVote, mockCache, mockLogger)
+
+	mockRepo.EXPECT().PurgeUser(gomock.Any(), "7").Return([]uint{2, 3}, nil)
+	mockCache.EXPECT().Delete(gomock.Any(), "user:2", "user:3").Return(errors.New("connection refused"))
+
+	// The purge succeeded, a failed eviction only leaves entries until they expire
+	err := userService.PurgeUser(context.Background(), "7")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/constants"
+	"gitlab.com/jkozhemiaka/web-layout/internal/logging"
+	"gitlab.com/jkozhemiaka/web-layout/internal/mailer"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	"gitlab.com/jkozhemiaka/web-layout/internal/repositories"
+	"go.uber.org/zap"
+)
+
+type VerificationService struct {
+	userRepo     repositories.UserRepoInterface
+	tokenService auth.TokenServiceInterface
+	mailer       mailer.MailerInterface
+	cache        cache.CacheInterface
+	logger       *zap.SugaredLogger
+	cfg          *config.Config
+}
+
+type VerificationServiceInterface interface {
+	SendVerificationEmail(ctx context.Context, user *models.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, email string) error
+}
+
+func NewVerificationService(userRepo repositories.UserRepoInterface, tokenService auth.TokenServiceInterface, mailer mailer.MailerInterface, cache cache.CacheInterface, logger *zap.SugaredLogger, cfg *config.Config) VerificationServiceInterface {
+	return &VerificationService{
+		userRepo:     userRepo,
+		tokenService: tokenService,
+		mailer:       mailer,
+		cache:        cache,
+		logger:       logger,
+		cfg:          cfg,
+	}
+}
+
+func (service *VerificationService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := service.tokenService.IssueEmailVerificationToken(ctx, user)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	link := fmt.Sprintf("%s/users/verify?token=%s", service.cfg.AppBaseURL, url.QueryEscape(token))
+	err = service.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\r\n\r\nplease confirm your email address by opening the link below:\r\n\r\n%s\r\n\r\nThe link expires in %s.\r\n",
+			user.FirstName, link, service.cfg.EmailVerificationTTL),
+	})
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (service *VerificationService) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := service.tokenService.ConsumeEmailVerificationToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	err = service.userRepo.MarkEmailVerified(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		if apperrors.Is(err, &apperrors.NoRecordFoundErr) {
+			// The user is gone or has changed the email since the token was sent
+			return &apperrors.InvalidVerificationTokenErr
+		}
+		return err
+	}
+
+	// The cached profile and lists still show the address as unverified
+	err = service.cache.Delete(ctx, constants.UserCacheKeyPrefix+strconv.FormatUint(uint64(claims.UserID), 10))
+	if err == nil {
+		err = service.cache.InvalidateTags(ctx, constants.UsersListCacheTag, constants.UsersCountCacheTag)
+	}
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+	}
+
+	return nil
+}
+
+// ResendVerificationEmail sends a new link to an unverified account. Unknown and
+// already verified addresses are silently ignored so the endpoint can't be used
+// to find out which emails are registered.
+func (service *VerificationService) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := service.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+	if user == nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	resendKey := constants.VerificationResendKeyPrefix + user.Email
+	_, err = service.cache.Get(ctx, resendKey, service.cfg.EmailVerificationResendDelay)
+	if err == nil {
+		// A link has been sent recently
+		return nil
+	}
+	if !errors.Is(err, cache.ErrCacheMiss) {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	err = service.cache.Set(ctx, resendKey, constants.TokenStatusUsed, service.cfg.EmailVerificationResendDelay)
+	if err != nil {
+		logging.FromContext(ctx, service.logger).Error(err)
+		return err
+	}
+
+	return service.SendVerificationEmail(ctx, user)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/apperrors"
+	"gitlab.com/jkozhemiaka/web-layout/internal/auth"
+	"gitlab.com/jkozhemiaka/web-layout/internal/cache"
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"gitlab.com/jkozhemiaka/web-layout/internal/mailer"
+	"gitlab.com/jkozhemiaka/web-layout/internal/models"
+	mocks "gitlab.com/jkozhemiaka/web-layout/internal/repositories/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestVerificationService_SendVerificationEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	cfg := &config.Config{AppBaseURL: "http://localhost:50052", EmailVerificationTTL: time.Hour}
+	verificationService := NewVerificationService(mockRepo, mockTokenService, mockMailer, mockCache, mockLogger, cfg)
+
+	user := &models.User{ID: 1, Email: "test@example.com", FirstName: "John"}
+	mockTokenService.EXPECT().IssueEmailVerificationToken(gomock.Any(), user).Return("signed-token", nil)
+	mockMailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg *mailer.Message) error {
+		assert.Equal(t, "test@example.com", msg.To)
+		assert.True(t, strings.Contains(msg.Body, "http://localhost:50052/users/verify?token=signed-token"))
+		return nil
+	})
+
+	err := verificationService.SendVerificationEmail(context.Background(), user)
+	assert.NoError(t, err)
+}
+
+func TestVerificationService_VerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	verificationService := NewVerificationService(mockRepo, mockTokenService, mockMailer, mockCache, mockLogger, &config.Config{})
+
+	claims := &auth.VerificationClaims{UserID: 1, Email: "test@example.com"}
+	mockTokenService.EXPECT().ConsumeEmailVerificationToken(gomock.Any(), "signed-token").Return(claims, nil)
+	mockRepo.EXPECT().MarkEmailVerified(gomock.Any(), uint(1), "test@example.com").Return(nil)
+	// The cached profile and lists are dropped
+	mockCache.EXPECT().Delete(gomock.Any(), "user:1").Return(nil)
+	mockCache.EXPECT().InvalidateTags(gomock.Any(), "users_list", "users_count").Return(nil)
+
+	err := verificationService.VerifyEmail(context.Background(), "signed-token")
+	assert.NoError(t, err)
+}
+
+func TestVerificationService_VerifyEmail_EmailChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	verificationService := NewVerificationService(mockRepo, mockTokenService, mockMailer, mockCache, mockLogger, &config.Config{})
+
+	claims := &auth.VerificationClaims{UserID: 1, Email: "old@example.com"}
+	mockTokenService.EXPECT().ConsumeEmailVerificationToken(gomock.Any(), "signed-token").Return(claims, nil)
+	mockRepo.EXPECT().MarkEmailVerified(gomock.Any(), uint(1), "old@example.com").Return(apperrors.NoRecordFoundErr.AppendMessage("User not found."))
+
+	err := verificationService.VerifyEmail(context.Background(), "signed-token")
+	assert.True(t, apperrors.Is(err, &apperrors.InvalidVerificationTokenErr))
+}
+
+func TestVerificationService_ResendVerificationEmail_AlreadyVerified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepoInterface(ctrl)
+	mockTokenService := auth.NewMockTokenServiceInterface(ctrl)
+	mockMailer := mailer.NewMockMailerInterface(ctrl)
+	mockCache := cache.NewMockCacheInterface(ctrl)
+	mockLogger := zaptest.NewLogger(t).Sugar()
+	verificationService := NewVerificationService(mockRepo, mockTokenService, mockMailer, mockCache, mockLogger, &config.Config{})
+
+	verifiedAt := time.Now()
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "test@example.com").Return(&models.User{ID: 1, EmailVerifiedAt: &verifiedAt}, nil)
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "unknown@example.com").Return(nil, nil)
+
+	// Nothing is sent, and no error tells the caller why
+	assert.NoError(t, verificationService.ResendVerificationEmail(context.Background(), "test@example.com"))
+	assert.NoError(t, verificationService.ResendVerificationEmail(context.Background(), "unknown@example.com"))
+}
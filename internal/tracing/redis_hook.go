@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook starts a span for every Redis command and pipeline
+type RedisHook struct{}
+
+func NewRedisHook() *RedisHook {
+	return &RedisHook{}
+}
+
+func (h *RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = otel.Tracer(InstrumentationName).Start(ctx, "redis."+cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation(cmd.Name())),
+	)
+	return ctx, nil
+}
+
+func (h *RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endSpan(trace.SpanFromContext(ctx), cmd.Err())
+	return nil
+}
+
+func (h *RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, _ = otel.Tracer(InstrumentationName).Start(ctx, "redis.pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, attribute.Int("db.redis.num_cmd", len(cmds))),
+	)
+	return ctx, nil
+}
+
+func (h *RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			err = cmdErr
+			break
+		}
+	}
+	endSpan(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+// endSpan ends the span, a missing key is not an error
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRedisHook_RecordsFailedCommand(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	// Nothing listens on the port, the command fails right away
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: time.Second})
+	defer client.Close()
+	client.AddHook(NewRedisHook())
+
+	err := client.Get(context.Background(), "key").Err()
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "redis.get", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/jkozhemiaka/web-layout/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// InstrumentationName names the tracers of the service
+const InstrumentationName = "gitlab.com/jkozhemiaka/web-layout"
+
+// NewTracerProvider returns a provider exporting spans to the exporter selected
+// by TRACING_EXPORTER. With none, spans are still created so that the incoming
+// traceparent is propagated, but they are not exported.
+func NewTracerProvider(ctx context.Context, cfg *config.Config) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.TracingServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	}
+
+	switch cfg.TracingExporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		clientOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.TracingOTLPEndpoint)}
+		if cfg.TracingOTLPInsecure {
+			clientOptions = append(clientOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, clientOptions...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unsupported TRACING_EXPORTER %q", cfg.TracingExporter)
+	}
+
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// Install makes the provider and the W3C trace context propagation global. The
+// tracers of the packages are taken from the global provider.
+func Install(provider *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}